@@ -17,22 +17,55 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
+	"log/syslog"
+	"maps"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/config"
 	"github.com/konflux-ci/renovate-log-analyzer/pkg/doctor"
 	"github.com/konflux-ci/renovate-log-analyzer/pkg/kite"
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/metrics"
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/output"
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/statsd"
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/tracing"
 )
 
+// Exit codes of the -fail-on findings, 1 is left for the analyzer failing itself
+const (
+	exitCodeErrors   = 2
+	exitCodeWarnings = 3
+)
+
+// findingsError ends a completed run with the exit code of the most severe finding
+type findingsError struct {
+	code   int
+	reason string
+}
+
+func (e *findingsError) Error() string {
+	return e.reason
+}
+
 func main() {
 	if err := run(); err != nil {
 		handler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})
 		logger := slog.New(handler)
+		var findings *findingsError
+		if errors.As(err, &findings) {
+			logger.Info("analysis completed with findings", "reason", findings.reason, "exitCode", findings.code)
+			os.Exit(findings.code)
+		}
 		logger.Error("application failed", "err", err)
 		os.Exit(1)
 	}
@@ -40,32 +73,118 @@ func main() {
 
 func run() error {
 	const defaultLogFilePath = "/workspace/shared-data/renovate-logs.json"
+	const stdinLogFile = "-"
+	startTime := time.Now()
 
 	// Set up slog logger
 	devMode := flag.Bool("dev", false, "Enable development mode (more verbose)")
+	quietMode := flag.Bool("quiet", false, "Enable quiet mode (only warnings, errors and the final result are logged)")
+	githubAnnotations := flag.Bool("github-annotations", false, "Print the report as GitHub Actions annotations")
+	strictFlag := flag.Bool("strict", false, "Enable strict mode (report warnings fail the run)")
+	sarifOut := flag.String("sarif-out", "", "Write the report as a SARIF document to the given path")
+	reportOut := flag.String("report-out", "", "Write the report and the fail reason as JSON to the given path, or to stdout for \"-\"")
+	failOnFlag := flag.String("fail-on", "", "Exit with a non-zero code when the report has findings of the given severity: error, warning or none")
+	dryRunFlag := flag.Bool("dry-run", false, "Log the webhooks instead of sending them to Kite")
+	tailFlag := flag.Bool("tail", false, "Keep reading the log file while it is written until Renovate completes")
+	preflightWebhooks := flag.Bool("preflight-webhooks", false, "Check that the Kite webhooks exist before sending any")
+	configFile := flag.String("config", "", "Load the settings from the given YAML or JSON file, the environment variables override them")
+	groupBranchErrorsFlag := flag.Bool("group-branch-errors", false, "Report the errors identical but for their branch once with the affected branches")
+	versionFlag := flag.Bool("version", false, "Print the version, git commit and build date, then exit")
 	flag.Parse()
 
+	if *versionFlag {
+		fmt.Println(versionString())
+		return nil
+	}
+
+	// The settings are the defaults, overridden by the config file, then the environment and then the flags
+	cfg := config.Default()
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		var err error
+		if cfg, err = config.Load(*configFile); err != nil {
+			return err
+		}
+	}
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return err
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "strict":
+			cfg.Strict = *strictFlag
+		case "fail-on":
+			cfg.FailOn = *failOnFlag
+		case "dry-run":
+			cfg.DryRun = *dryRunFlag
+		case "tail":
+			cfg.Tail = *tailFlag
+		case "group-branch-errors":
+			cfg.GroupBranchErrors = *groupBranchErrorsFlag
+		}
+	})
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+
+	logLevel := new(slog.LevelVar)
 	opts := &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: logLevel,
 	}
 	if *devMode {
-		opts.Level = slog.LevelDebug
+		logLevel.Set(slog.LevelDebug)
 		opts.AddSource = true // Show source location in dev mode
+	} else if *quietMode {
+		logLevel.Set(slog.LevelWarn)
 	}
 
-	handler := slog.NewJSONHandler(os.Stdout, opts)
+	// The logs go to stderr when the JSON report is written to stdout, so that it stays parseable
+	var console io.Writer = os.Stdout
+	if *reportOut == "-" {
+		if *githubAnnotations {
+			return fmt.Errorf("-report-out - and -github-annotations can't both write to stdout")
+		}
+		console = os.Stderr
+	}
+
+	// Optionally send the logs to a syslog endpoint alongside the console
+	logOutput := console
+	if syslogAddr := cfg.SyslogAddr; syslogAddr != "" {
+		syslogWriter, err := dialSyslog(syslogAddr)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog at %s: %w", syslogAddr, err)
+		}
+		defer syslogWriter.Close()
+
+		if cfg.SyslogOnly {
+			logOutput = syslogWriter
+		} else {
+			logOutput = io.MultiWriter(console, syslogWriter)
+		}
+	}
+
+	handler := slog.NewJSONHandler(logOutput, opts)
 	logger := slog.New(handler).With("name", "log-analyzer")
 
-	// Get the necessary environment variables
-	kiteAPIURL := getEnvOrDefault("KITE_API_URL", "")
-	namespace := getEnvOrDefault("NAMESPACE", "")
+	// Get the necessary settings
+	kiteAPIURL := cfg.KiteAPIURL
+	namespace := cfg.Namespace
 
-	logFilePath := getEnvOrDefault("LOG_FILE", defaultLogFilePath)
+	logFilePath := cfg.LogFile
+	// Read the logs from stdin with LOG_FILE=- or an empty LOG_FILE when stdin is piped
+	if logFilePath == "" {
+		logFilePath = defaultLogFilePath
+		if isStdinPipe() {
+			logFilePath = stdinLogFile
+		}
+	}
 
-	pipelineRunName := getEnvOrDefault("PIPELINE_RUN", "unknown")
-	gitHost := getEnvOrDefault("GIT_HOST", "unknown")
-	repository := getEnvOrDefault("REPOSITORY", "unknown")
-	branch := getEnvOrDefault("BRANCH", "unknown")
+	pipelineRunName := cfg.PipelineRun
+	gitHost := cfg.GitHost
+	repository := cfg.Repository
+	branch := cfg.Branch
 	logger = logger.With(
 		"pipelineRun", pipelineRunName,
 		"gitHost", gitHost,
@@ -73,39 +192,257 @@ func run() error {
 		"branch", branch,
 	)
 
+	// Namespaces can be routed to their own Kite instance, KITE_API_URL is the fallback
+	kiteAPIURLs, err := loadKiteAPIURLs(cfg.KiteAPIURLMapFile, cfg.KiteAPIURLMap)
+	if err != nil {
+		return fmt.Errorf("invalid Kite API URL mapping: %w", err)
+	}
+	if namespaceURL, found := kiteAPIURLs[namespace]; found && namespace != "" {
+		kiteAPIURL = namespaceURL
+	}
+
 	if namespace == "" || kiteAPIURL == "" {
-		return fmt.Errorf("missing required environment variables: NAMESPACE and KITE_API_URL must be set")
+		return fmt.Errorf("missing required environment variables: NAMESPACE and KITE_API_URL (or a Kite API URL mapping for the namespace) must be set")
 	}
 	logger = logger.With("namespace", namespace)
 
+	// An empty list of severities sends no custom webhooks
+	customSeverities := make(map[string]bool)
+	for _, severity := range cfg.CustomWebhookSeverities {
+		customSeverities[severity] = true
+	}
+	strictMode := cfg.Strict
+	failOn := cfg.FailOn
+
 	// Now use the logger throughout your code
-	logger.Info("Starting log analyzer tool")
+	ver, rev, _ := buildVersion()
+	logger.Info("Starting log analyzer tool", "version", ver, "commit", rev)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Warn("failed to flush traces", "err", err)
+		}
+	}()
+
 	pipelineIdentifier := fmt.Sprintf("%s/%s@%s", gitHost, repository, branch)
+	logsURL := expandURLTemplate(cfg.LogsURL, map[string]string{
+		"namespace":   namespace,
+		"pipelineRun": pipelineRunName,
+		"gitHost":     gitHost,
+		"repository":  repository,
+		"branch":      branch,
+	})
 
 	// Step 2: Process logs if step-renovate ran
 	var processedFailReason string
-	processedFailReason, report, err := doctor.ProcessLogFile(ctx, logFilePath)
+
+	// Project-specific critical lines to keep when shortening long messages
+	criticalPatterns, err := loadPatternsFile(cfg.CriticalPatternsFile)
+	if err != nil {
+		return err
+	}
+	criticalPatterns = append(criticalPatterns, cfg.CriticalPatterns...)
+	if err := doctor.AddCriticalPatterns(criticalPatterns); err != nil {
+		return err
+	}
+	// Project-specific secrets masked in the report and the fail reason besides the built-in ones
+	if len(cfg.RedactPatterns) > 0 {
+		if err := doctor.AddRedactionPatterns(cfg.RedactPatterns); err != nil {
+			return err
+		}
+	}
+
+	startOffset := cfg.LogFileStartOffset
+	processOpts := doctor.Options{
+		ValidateSchema:   cfg.ValidateLogSchema,
+		FileWaitAttempts: cfg.LogFileWaitAttempts,
+		FileWaitInterval: time.Duration(cfg.LogFileWaitInterval),
+		OptionalChecks:   cfg.OptionalChecks,
+		StartOffset:      startOffset,
+		NoisyDeps:        cfg.NoisyDependencies,
+		LineNumbers:      cfg.IncludeLineNumbers,
+		WarningPatterns:  cfg.WarningNormalizePatterns,
+		Branch:           cfg.FilterBranch,
+		MaxLineBytes:     cfg.MaxLogLineBytes,
+		MaxErrorLines:    cfg.MaxErrorLines,
+		Tail:             cfg.Tail,
+		TailUntil:        cfg.TailUntil,
+		TailInterval:     time.Duration(cfg.TailInterval),
+		TailTimeout:      time.Duration(cfg.TailTimeout),
+		Logger:           logger,
+	}
+	// The checks settings turn off built-in selectors, change their severities and add selectors
+	if processOpts.Selectors, processOpts.SelectorModes, err = cfg.SelectorChecks(); err != nil {
+		return err
+	}
+	// Several comma-separated log files or a directory of them are analyzed as one
+	var logFilePaths []string
+	if logFilePath != stdinLogFile {
+		if logFilePaths, err = resolveLogFiles(logFilePath); err != nil {
+			return err
+		}
+		if len(logFilePaths) > 1 && startOffset != 0 {
+			return fmt.Errorf("LOG_FILE_START_OFFSET can't be used with multiple log files")
+		}
+	}
+
+	processCtx, processSpan := tracing.Start(ctx, "process-logs")
+	var report *doctor.SimpleReport
+	switch {
+	case logFilePath == stdinLogFile:
+		processedFailReason, report, err = doctor.ProcessLogReader(processCtx, os.Stdin, processOpts)
+	case len(logFilePaths) == 1:
+		processedFailReason, report, err = doctor.ProcessLogFile(processCtx, logFilePaths[0], processOpts)
+	default:
+		var missing []string
+		processedFailReason, report, missing, err = doctor.ProcessLogFiles(processCtx, logFilePaths, processOpts)
+		if err == nil || errors.Is(err, doctor.ErrNoLogOutput) {
+			for _, path := range missing {
+				logger.Warn("Log file not found, analyzing the other log files", "path", path)
+			}
+		}
+	}
+	// step-renovate ran without logging anything, which fails the run instead of passing as clean
+	if errors.Is(err, doctor.ErrNoLogOutput) {
+		logger.Warn("The log file has no parseable log lines", "linesProcessed", report.Stats.LinesProcessed,
+			"parseErrors", report.Stats.ParseErrors)
+		processedFailReason, err = err.Error(), nil
+	}
+	processSpan.SetAttribute("log.lines", report.Stats.LinesProcessed)
+	processSpan.SetAttribute("log.parse_errors", report.Stats.ParseErrors)
+	processSpan.SetAttribute("report.errors", len(report.Errors))
+	processSpan.SetAttribute("report.warnings", len(report.Warnings))
+	processSpan.SetAttribute("report.infos", len(report.Infos))
+	processSpan.SetAttribute("failed", processedFailReason != "")
+	processSpan.End(err)
 	if err != nil {
 		// Exit since we couldn't analyze logs at all
 		return fmt.Errorf("failed to process logs: %w", err)
 	}
+	// Optionally report the errors identical but for their branch once with the affected branches
+	if cfg.GroupBranchErrors {
+		report.GroupBranchErrors()
+	}
+	if cfg.CorrelateErrors {
+		report.CorrelateErrors()
+	}
 	logger.Info("Successfully processed logs",
 		"failureLogs", processedFailReason,
 		"reportErrors", report.Errors,
 		"reportWarnings", report.Warnings,
 		"reportInfos", report.Infos,
+		"logFileOffset", report.Stats.EndOffset,
+		"linesProcessed", report.Stats.LinesProcessed,
+		"parseErrors", report.Stats.ParseErrors,
+		"skippedLines", report.Stats.SkippedLines,
 	)
+	// Mostly unparseable lines usually mean the file isn't Renovate's JSON log
+	if report.Stats.ParseErrors > 0 && report.Stats.ParseErrors*2 > report.Stats.LinesProcessed {
+		logger.Warn("Most log lines are not valid JSON, the log file may not be a Renovate JSON log",
+			"parseErrors", report.Stats.ParseErrors, "linesProcessed", report.Stats.LinesProcessed)
+	}
+	if report.Stats.Downgraded > 0 {
+		logger.Info("Downgraded errors and warnings of noisy dependencies to infos",
+			"downgraded", report.Stats.Downgraded)
+	}
+	if report.Stats.SchemaViolations > 0 {
+		logger.Warn("Log lines not matching the expected Renovate log schema, the log format may have changed",
+			"schemaViolations", report.Stats.SchemaViolations)
+	}
+
+	// Per-run metrics are recorded once the run is over, whatever its result
+	var recorders []metrics.Recorder
+	if statsdAddr := cfg.StatsdAddr; statsdAddr != "" {
+		statsdTags := maps.Clone(cfg.StatsdTags)
+		if statsdTags == nil {
+			statsdTags = make(map[string]string)
+		}
+		if _, found := statsdTags["namespace"]; !found {
+			statsdTags["namespace"] = namespace
+		}
+		recorders = append(recorders, statsdRecorder{
+			addr:   statsdAddr,
+			prefix: cfg.StatsdPrefix,
+			tags:   statsdTags,
+		})
+	}
+	metricsLabels := map[string]string{"namespace": namespace, "pipeline": pipelineIdentifier}
+	if metricsFile := cfg.MetricsFile; metricsFile != "" {
+		recorders = append(recorders, metrics.TextFileRecorder{Path: metricsFile, Labels: metricsLabels})
+	}
+	if metricsURL := cfg.MetricsURL; metricsURL != "" {
+		recorders = append(recorders, metrics.PushgatewayRecorder{
+			URL:    metricsURL,
+			Job:    cfg.MetricsJob,
+			Labels: metricsLabels,
+		})
+	}
+	var webhooks *kiteSink
+	defer func() {
+		run := metrics.Run{
+			Failed:         processedFailReason != "",
+			Errors:         len(report.Errors),
+			Warnings:       len(report.Warnings),
+			Infos:          len(report.Infos),
+			LinesProcessed: report.Stats.LinesProcessed,
+			ParseErrors:    report.Stats.ParseErrors,
+			Duration:       time.Since(startTime),
+			Time:           time.Now(),
+		}
+		if webhooks != nil {
+			run.WebhooksSent, run.WebhooksFailed = webhooks.webhooksSent, webhooks.webhooksFailed
+		}
+		for _, recorder := range recorders {
+			if err := recorder.Record(ctx, run); err != nil {
+				logger.Warn("failed to record metrics", "err", err)
+			}
+		}
+	}()
+
+	// Errors are the ERROR and FATAL log entries and the report errors, before strict mode adds the warnings
+	foundErrors := processedFailReason != "" || len(report.Errors) > 0
+
+	// In strict mode any report warning fails the run
+	strictFailure := strictMode && len(report.Warnings) > 0
+	if strictFailure && processedFailReason == "" {
+		processedFailReason = fmt.Sprintf("Mintmaker finished with %d WARNING in strict mode: %s",
+			len(report.Warnings), strings.Join(report.Warnings, "\n"))
+	}
 
 	if *devMode {
-		fmt.Println("----- Log Analysis Result -----")
-		fmt.Println("Fail logs:\n", processedFailReason)
-		fmt.Println("Report Errors:\n", strings.Join(report.Errors, "\n-------------\n"))
-		fmt.Println("Report Warnings:\n", strings.Join(report.Warnings, "\n-------------\n"))
-		fmt.Println("-----------------------------")
+		fmt.Fprintln(console, "----- Log Analysis Result -----")
+		fmt.Fprintln(console, "Fail logs:\n", processedFailReason)
+		fmt.Fprintln(console, "Report Errors:\n", strings.Join(report.Errors, "\n-------------\n"))
+		fmt.Fprintln(console, "Report Warnings:\n", strings.Join(report.Warnings, "\n-------------\n"))
+		fmt.Fprintln(console, "-----------------------------")
+	}
+
+	// Output sinks the report is emitted to, in order
+	var sinks []output.ReportSink
+	if *githubAnnotations {
+		sinks = append(sinks, output.GitHubAnnotationsSink{Writer: os.Stdout})
+	}
+	if *sarifOut != "" {
+		sinks = append(sinks, output.SARIFFileSink{Path: *sarifOut})
+	}
+	if *reportOut != "" {
+		sinks = append(sinks, output.JSONFileSink{Path: *reportOut, PipelineIdentifier: pipelineIdentifier, Namespace: namespace})
+	}
+
+	baselinePath := cfg.BaselineReport
+	baseline, err := loadBaselineReport(baselinePath)
+	if err != nil {
+		return err
+	}
+	if baselinePath != "" && baseline == nil {
+		logger.Info("Baseline report not found, all findings are sent", "path", baselinePath)
 	}
 
 	// Create Kite client
@@ -114,115 +451,238 @@ func run() error {
 		return fmt.Errorf("failed to create Kite client for %s: %w", kiteAPIURL, err)
 	}
 
-	kiteStatus, err := kiteClient.GetKiteStatus(ctx)
-	if err != nil {
-		return fmt.Errorf("request for Kite API status failed at %s: %w", kiteAPIURL, err)
+	kiteClient.SetRequestTimeout(time.Duration(cfg.KiteRequestTimeout))
+
+	// Without a CA file the default transport is used, still honoring HTTPS_PROXY and NO_PROXY
+	if caFile := cfg.KiteCAFile; caFile != "" {
+		if err := kiteClient.SetCAFile(caFile); err != nil {
+			return fmt.Errorf("invalid KITE_CA_FILE: %w", err)
+		}
+	}
+
+	if cfg.DryRun {
+		kiteClient.SetDryRun(logger)
+	}
+	// The token is only handed to the client, it must never be logged
+	if kiteAPIToken := cfg.KiteAPIToken; kiteAPIToken != "" {
+		kiteClient.SetToken(kiteAPIToken)
+	}
+	if deadLetterFile := cfg.DeadLetterFile; deadLetterFile != "" {
+		kiteClient.SetDeadLetterFile(deadLetterFile)
 	}
-	logger.Info("Kite API status request completed", "status", kiteStatus, "apiURL", kiteAPIURL)
 
-	// Send custom webhooks (only if we have log analysis)
-	if len(report.Errors) > 0 || len(report.Warnings) > 0 || len(report.Infos) > 0 {
-		sendCustomWebhooks(ctx, logger, kiteClient, namespace, pipelineIdentifier, report)
+	webhooks = &kiteSink{
+		logger:             logger,
+		client:             kiteClient,
+		apiURL:             kiteAPIURL,
+		namespace:          namespace,
+		gitHost:            gitHost,
+		repository:         repository,
+		branch:             branch,
+		pipelineIdentifier: pipelineIdentifier,
+		runID:              pipelineRunName,
+		labels:             cfg.WebhookLabels,
+		logsURL:            logsURL,
+		baseline:           baseline,
+		includeSelectors:   cfg.CustomWebhookIncludeSelector,
+		customSeverities:   customSeverities,
+		splitFailures:      cfg.SplitFailureWebhook,
+		deadLetterWarn:     cfg.DeadLetterVerdict == "warn", // dead-lettered success/failure webhooks otherwise fail the run
+		preflightWebhooks:  *preflightWebhooks,
+		sendHeartbeat:      cfg.SendHeartbeat,
 	}
+	sinks = append(sinks, webhooks)
 
-	// Send success or failure webhook
-	if processedFailReason == "" {
-		if err := sendSuccessWebhook(ctx, kiteClient, namespace, pipelineIdentifier); err != nil {
-			return fmt.Errorf("failed to send success webhook: %w", err)
+	verdict := output.Verdict{FailReason: processedFailReason}
+	for _, sink := range sinks {
+		if err := sink.Emit(ctx, report, verdict); err != nil {
+			return err
 		}
-		logger.Info("Successfully sent success webhook")
+	}
+
+	// The final result is logged even in quiet mode
+	logLevel.Set(min(logLevel.Level(), slog.LevelInfo))
+	if webhooks.webhooksSkipped {
+		logger.Info("Completed log analysis, the webhooks were skipped")
 	} else {
-		if err := sendFailureWebhook(ctx, kiteClient, namespace, pipelineIdentifier,
-			pipelineRunName, processedFailReason); err != nil {
-			return fmt.Errorf("failed to send failure webhook: %w", err)
-		}
-		logger.Info("Successfully sent failure webhook", "failureMsg", processedFailReason)
+		logger.Info("Successfully completed log analysis and sent webhook")
 	}
 
-	logger.Info("Successfully completed log analysis and sent webhook")
+	// The webhooks are sent, the findings only decide the exit code
+	switch {
+	case failOn != "none" && foundErrors:
+		return &findingsError{code: exitCodeErrors, reason: fmt.Sprintf("fail-on %s: the logs contain errors, %d in the report", failOn, len(report.Errors))}
+	case strictFailure:
+		return &findingsError{code: exitCodeWarnings, reason: fmt.Sprintf("strict mode: the report contains %d warnings", len(report.Warnings))}
+	case failOn == "warning" && len(report.Warnings) > 0:
+		return &findingsError{code: exitCodeWarnings, reason: fmt.Sprintf("fail-on warning: the report contains %d warnings", len(report.Warnings))}
+	}
 	return nil
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if val := os.Getenv(key); val != "" {
-		return val
-	}
-	return defaultValue
-}
+// resolveLogFiles splits the comma-separated log file paths, replacing directories with the files in them
+func resolveLogFiles(value string) ([]string, error) {
+	var paths []string
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			// Missing files are waited for or skipped when processing
+			paths = append(paths, path)
+			continue
+		}
 
-func sendCustomWebhooks(ctx context.Context, logger *slog.Logger, kiteClient *kite.Client, namespace, pipelineIdentifier string, report *doctor.SimpleReport) {
-	sentTypes := ""
-	if len(report.Errors) > 0 {
-		if err := sendCustomWebhook(ctx, kiteClient, namespace, pipelineIdentifier, "error", report.Errors); err != nil {
-			logger.Error("failed to send error webhook", "err", err)
-		} else {
-			sentTypes += "error "
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log directory %s: %w", path, err)
 		}
-	}
-	if len(report.Warnings) > 0 {
-		if err := sendCustomWebhook(ctx, kiteClient, namespace, pipelineIdentifier, "warning", report.Warnings); err != nil {
-			logger.Error("failed to send warning webhook", "err", err)
-		} else {
-			sentTypes += "warning "
+		found := false
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				paths = append(paths, filepath.Join(path, entry.Name()))
+				found = true
+			}
 		}
-	}
-	if len(report.Infos) > 0 {
-		if err := sendCustomWebhook(ctx, kiteClient, namespace, pipelineIdentifier, "info", report.Infos); err != nil {
-			logger.Error("failed to send info webhook", "err", err)
-		} else {
-			sentTypes += "info"
+		if !found {
+			return nil, fmt.Errorf("no log files found in directory %s", path)
 		}
 	}
-	if sentTypes != "" {
-		logger.Info("Successfully sent custom webhooks", "types", sentTypes)
-	} else {
-		logger.Info("Custom webhooks were not sent", "errors", len(report.Errors), "warnings", len(report.Warnings), "infos", len(report.Infos))
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no log file set in LOG_FILE")
 	}
+	return paths, nil
 }
 
-func sendCustomWebhook(ctx context.Context, kiteClient *kite.Client, namespace, pipelineIdentifier, issueType string, logs []string) error {
-	payload := kite.CustomPayload{
-		PipelineId: pipelineIdentifier,
-		Namespace:  namespace,
-		Type:       issueType,
-		Logs:       logs,
+// loadPatternsFile reads one regex per line from the file, skipping empty lines and # comments
+func loadPatternsFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
 	}
 
-	marshaledPayload, err := json.Marshal(payload)
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("unable to marshal payload: %w", err)
+		return nil, fmt.Errorf("failed to read patterns file %s: %w", path, err)
 	}
 
-	return kiteClient.SendWebhookRequest(ctx, namespace, "mintmaker-custom", marshaledPayload)
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
 }
 
-func sendSuccessWebhook(ctx context.Context, kiteClient *kite.Client, namespace, pipelineIdentifier string) error {
-	payload := kite.PipelineSuccessPayload{
-		PipelineName: pipelineIdentifier,
-		Namespace:    namespace,
+// loadBaselineReport reads the JSON report of a previous run, a missing file yields no baseline
+func loadBaselineReport(path string) (*doctor.SimpleReport, error) {
+	if path == "" {
+		return nil, nil
 	}
 
-	marshaledPayload, err := json.Marshal(payload)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
 	if err != nil {
-		return fmt.Errorf("unable to marshal payload: %w", err)
+		return nil, fmt.Errorf("failed to read baseline report %s: %w", path, err)
+	}
+
+	var baseline output.JSONReport
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline report %s: %w", path, err)
+	}
+	if baseline.Baseline == nil {
+		return nil, fmt.Errorf("failed to parse baseline report %s: missing baseline", path)
 	}
+	return baseline.Baseline.Report(), nil
+}
 
-	return kiteClient.SendWebhookRequest(ctx, namespace, "pipeline-success", marshaledPayload)
+// statsdRecorder sends the metrics of the run to a StatsD endpoint
+type statsdRecorder struct {
+	addr   string
+	prefix string
+	tags   map[string]string
 }
 
-func sendFailureWebhook(ctx context.Context, kiteClient *kite.Client, namespace, pipelineIdentifier, runID, failReason string) error {
-	payload := kite.PipelineFailurePayload{
-		PipelineName:  pipelineIdentifier,
-		Namespace:     namespace,
-		FailureReason: failReason,
-		RunID:         runID,
-		LogsURL:       "",
+func (r statsdRecorder) Record(_ context.Context, run metrics.Run) error {
+	client, err := statsd.NewClient(r.addr, r.prefix, r.tags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	failures := 0
+	if run.Failed {
+		failures = 1
 	}
 
-	marshaledPayload, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("unable to marshal payload: %w", err)
+	if err := errors.Join(
+		client.Count("runs", 1),
+		client.Gauge("failed", failures),
+		client.Gauge("errors", run.Errors),
+		client.Gauge("warnings", run.Warnings),
+		client.Gauge("infos", run.Infos),
+		client.Gauge("lines_processed", run.LinesProcessed),
+		client.Gauge("parse_errors", run.ParseErrors),
+		client.Gauge("webhooks_sent", run.WebhooksSent),
+		client.Gauge("webhooks_failed", run.WebhooksFailed),
+		client.Timing("duration", run.Duration),
+	); err != nil {
+		return fmt.Errorf("failed to send StatsD metrics to %s: %w", r.addr, err)
 	}
+	return nil
+}
+
+// urlTemplateVarRe matches the {name} placeholders of a URL template
+var urlTemplateVarRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// expandURLTemplate replaces the {name} placeholders of the template with the given values,
+// unknown placeholders are kept as they are
+func expandURLTemplate(template string, values map[string]string) string {
+	return urlTemplateVarRe.ReplaceAllStringFunc(template, func(placeholder string) string {
+		if value, found := values[placeholder[1:len(placeholder)-1]]; found {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// isStdinPipe checks if stdin is a pipe or a file rather than a terminal
+func isStdinPipe() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// dialSyslog connects to the syslog endpoint given as "[network://]host:port",
+// using UDP when no network is specified
+func dialSyslog(addr string) (*syslog.Writer, error) {
+	network := "udp"
+	if before, after, found := strings.Cut(addr, "://"); found {
+		network, addr = before, after
+	}
+	return syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, "log-analyzer")
+}
+
+// loadKiteAPIURLs loads the namespace to Kite API URL mapping from a JSON file
+// and the namespace=url pairs, the pairs take precedence over the file
+func loadKiteAPIURLs(filePath string, pairURLs map[string]string) (map[string]string, error) {
+	urls := make(map[string]string)
+	if filePath != "" {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+		if err := json.Unmarshal(data, &urls); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+		}
+	}
+
+	maps.Copy(urls, pairURLs)
 
-	return kiteClient.SendWebhookRequest(ctx, namespace, "pipeline-failure", marshaledPayload)
+	return urls, nil
 }
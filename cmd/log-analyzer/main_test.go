@@ -0,0 +1,53 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestExpandURLTemplate(t *testing.T) {
+	values := map[string]string{
+		"namespace":   "team-tenant",
+		"pipelineRun": "renovate-abc12",
+		"branch":      "",
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "no placeholders", template: "https://console.example.com/logs", want: "https://console.example.com/logs"},
+		{name: "empty template", template: "", want: ""},
+		{
+			name:     "known placeholders",
+			template: "https://console.example.com/ns/{namespace}/pipelineruns/{pipelineRun}/logs",
+			want:     "https://console.example.com/ns/team-tenant/pipelineruns/renovate-abc12/logs",
+		},
+		{
+			name:     "missing variable is kept",
+			template: "https://console.example.com/{namespace}/{repository}",
+			want:     "https://console.example.com/team-tenant/{repository}",
+		},
+		{name: "empty value", template: "https://console.example.com/{branch}/logs", want: "https://console.example.com//logs"},
+		{name: "unclosed placeholder", template: "https://console.example.com/{namespace", want: "https://console.example.com/{namespace"},
+		{name: "empty placeholder", template: "https://console.example.com/{}", want: "https://console.example.com/{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandURLTemplate(tt.template, values); got != tt.want {
+				t.Errorf("expandURLTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
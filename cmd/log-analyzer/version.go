@@ -0,0 +1,64 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build metadata set with -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=...",
+// the Go build info fills in the ones left out, e.g. for go install or a local git checkout
+var (
+	version   = ""
+	commit    = ""
+	buildDate = ""
+)
+
+// buildVersion returns the version, the git commit and the build date of the binary, the date falling back
+// to the commit time of a local build; "dev" and "unknown" stand for the metadata that isn't known
+func buildVersion() (string, string, string) {
+	ver, rev, date := version, commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if ver == "" && info.Main.Version != "(devel)" {
+			ver = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && rev == "":
+				rev = setting.Value
+			case setting.Key == "vcs.time" && date == "":
+				date = setting.Value
+			}
+		}
+	}
+
+	if ver == "" {
+		ver = "dev"
+	}
+	if rev == "" {
+		rev = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return ver, rev, date
+}
+
+// versionString formats the build metadata printed by -version
+func versionString() string {
+	ver, rev, date := buildVersion()
+	return fmt.Sprintf("renovate-log-analyzer %s (commit %s, built %s)", ver, rev, date)
+}
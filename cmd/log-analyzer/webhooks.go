@@ -0,0 +1,306 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/doctor"
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/kite"
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/output"
+)
+
+// kiteSink sends the report to Kite as webhooks
+type kiteSink struct {
+	logger *slog.Logger
+	client *kite.Client
+	apiURL string
+
+	namespace          string
+	gitHost            string
+	repository         string
+	branch             string
+	pipelineIdentifier string
+	runID              string
+	labels             map[string]string
+	logsURL            string               // link to the pipeline logs sent with the failure webhooks
+	baseline           *doctor.SimpleReport // custom webhooks only report entries missing from it
+
+	includeSelectors  bool            // prefix the custom webhook logs with their selector
+	customSeverities  map[string]bool // severities sent as custom webhooks, all of them if nil
+	splitFailures     bool            // send a failure webhook per repository
+	deadLetterWarn    bool            // only warn about dead-lettered success/failure webhooks
+	preflightWebhooks bool            // check that the webhooks exist before sending any
+	sendHeartbeat     bool            // send a heartbeat webhook on every run
+
+	webhooksSent    int
+	webhooksFailed  int
+	webhooksSkipped bool // the Kite API was down
+}
+
+// Emit checks the Kite API status and sends the custom, success or failure and heartbeat webhooks
+func (s *kiteSink) Emit(ctx context.Context, report *doctor.SimpleReport, verdict output.Verdict) error {
+	health, err := s.client.GetKiteHealth(ctx)
+	if errors.Is(err, kite.ErrUnauthorized) {
+		return fmt.Errorf("Kite API at %s rejected the credentials, check KITE_API_TOKEN: %w", s.apiURL, err)
+	}
+	if err != nil {
+		return fmt.Errorf("request for Kite API status failed at %s: %w", s.apiURL, err)
+	}
+	// Every webhook would fail against a Kite API reporting itself down
+	if health.IsDown() {
+		s.logger.Warn("Kite API is down, skipping the webhooks", "status", health.String(), "apiURL", s.apiURL)
+		s.webhooksSkipped = true
+		return nil
+	}
+	s.logger.Info("Kite API status request completed", "status", health.String(), "apiURL", s.apiURL)
+
+	if s.preflightWebhooks {
+		for _, webhookName := range []string{"pipeline-success", "pipeline-failure", "mintmaker-custom"} {
+			exists, err := s.client.WebhookExists(ctx, webhookName)
+			if err != nil {
+				return fmt.Errorf("failed to check Kite webhook %s: %w", webhookName, err)
+			}
+			if !exists {
+				return fmt.Errorf("Kite webhook %s is not registered at %s", webhookName, s.apiURL)
+			}
+		}
+		s.logger.Info("Kite webhooks preflight check passed")
+	}
+
+	// Every webhook of the run carries the fingerprint of the full report, even when the custom webhooks only send new findings
+	fingerprint := report.Fingerprint()
+	// With a baseline from the previous run only new findings are sent as custom webhooks
+	customReport := report
+	if s.baseline != nil {
+		diff := doctor.DiffReports(s.baseline, report)
+		customReport = diff.New
+		s.logger.Info("Compared the report against the baseline report",
+			"new", len(diff.New.Errors)+len(diff.New.Warnings)+len(diff.New.Infos),
+			"resolved", len(diff.Resolved.Errors)+len(diff.Resolved.Warnings)+len(diff.Resolved.Infos))
+	}
+
+	// Send custom webhooks (only if we have log analysis)
+	if len(customReport.Errors) > 0 || len(customReport.Warnings) > 0 || len(customReport.Infos) > 0 {
+		s.sendCustomWebhooks(ctx, customReport, fingerprint)
+	}
+
+	// Send success or failure webhook
+	if verdict.FailReason == "" {
+		if err := s.sendSuccessWebhook(ctx, report, fingerprint); err == nil {
+			s.logger.Info("Successfully sent success webhook")
+		} else if s.isDeadLetterWarning(err) {
+			s.logger.Warn("Failed to send success webhook, it was written to the dead-letter file", "err", err)
+		} else {
+			return fmt.Errorf("failed to send success webhook: %w", err)
+		}
+	} else if s.splitFailures && len(report.RepositoryFailures) > 1 {
+		// Merged multi-repository logs, each repository gets its own failure
+		if err := s.sendRepositoryFailureWebhooks(ctx, report, fingerprint); err == nil {
+			s.logger.Info("Successfully sent failure webhooks", "repositories", len(report.RepositoryFailures))
+		} else if s.isDeadLetterWarning(err) {
+			s.logger.Warn("Failed to send failure webhooks, they were written to the dead-letter file", "err", err)
+		} else {
+			return fmt.Errorf("failed to send failure webhooks: %w", err)
+		}
+	} else {
+		if err := s.sendFailureWebhook(ctx, s.pipelineIdentifier, verdict.FailReason, fingerprint); err == nil {
+			s.logger.Info("Successfully sent failure webhook", "failureMsg", verdict.FailReason)
+		} else if s.isDeadLetterWarning(err) {
+			s.logger.Warn("Failed to send failure webhook, it was written to the dead-letter file", "err", err)
+		} else {
+			return fmt.Errorf("failed to send failure webhook: %w", err)
+		}
+	}
+
+	// The heartbeat is sent on every run to monitor the analyzer itself
+	if s.sendHeartbeat {
+		if err := s.sendHeartbeatWebhook(ctx, verdict.FailReason != "", report, fingerprint); err != nil {
+			s.logger.Error("failed to send heartbeat webhook", "err", err)
+		} else {
+			s.logger.Info("Successfully sent heartbeat webhook")
+		}
+	}
+
+	return nil
+}
+
+func (s *kiteSink) sendCustomWebhooks(ctx context.Context, report *doctor.SimpleReport, fingerprint string) {
+	errorLogs, warningLogs, infoLogs := report.Errors, report.Warnings, report.Infos
+	if s.includeSelectors {
+		errorLogs = prefixSelectors(report, errorLogs)
+		warningLogs = prefixSelectors(report, warningLogs)
+		infoLogs = prefixSelectors(report, infoLogs)
+	}
+
+	severities := []struct {
+		name string
+		logs []string
+	}{
+		{"error", errorLogs},
+		{"warning", warningLogs},
+		{"info", infoLogs},
+	}
+	var sentTypes, suppressedTypes []string
+	for _, severity := range severities {
+		if len(severity.logs) == 0 {
+			continue
+		}
+		if s.customSeverities != nil && !s.customSeverities[severity.name] {
+			suppressedTypes = append(suppressedTypes, severity.name)
+			continue
+		}
+		if err := s.sendCustomWebhook(ctx, severity.name, severity.logs, fingerprint); err != nil {
+			s.logger.Error(fmt.Sprintf("failed to send %s webhook", severity.name), "err", err)
+		} else {
+			sentTypes = append(sentTypes, severity.name)
+		}
+	}
+	if len(suppressedTypes) > 0 {
+		s.logger.Info("Custom webhooks suppressed by CUSTOM_WEBHOOK_SEVERITIES", "types", strings.Join(suppressedTypes, " "))
+	}
+	if len(sentTypes) > 0 {
+		s.logger.Info("Successfully sent custom webhooks", "types", strings.Join(sentTypes, " "))
+	} else {
+		s.logger.Info("Custom webhooks were not sent", "errors", len(report.Errors), "warnings", len(report.Warnings), "infos", len(report.Infos))
+	}
+}
+
+// prefixSelectors prefixes each log with the selector of the check that produced it
+func prefixSelectors(report *doctor.SimpleReport, logs []string) []string {
+	prefixed := make([]string, 0, len(logs))
+	for _, log := range logs {
+		if selector := report.Selector(log); selector != "" {
+			log = fmt.Sprintf("[%s] %s", selector, log)
+		}
+		prefixed = append(prefixed, log)
+	}
+	return prefixed
+}
+
+func (s *kiteSink) sendCustomWebhook(ctx context.Context, issueType string, logs []string, fingerprint string) error {
+	payload := kite.CustomPayload{
+		PipelineId:  s.pipelineIdentifier,
+		Namespace:   s.namespace,
+		Repository:  s.repository,
+		Branch:      s.branch,
+		PipelineRun: s.runID,
+		Type:        issueType,
+		Logs:        logs,
+		Labels:      s.labels,
+		Fingerprint: fingerprint,
+	}
+
+	return s.sendWebhook(ctx, "mintmaker-custom", payload)
+}
+
+func (s *kiteSink) sendSuccessWebhook(ctx context.Context, report *doctor.SimpleReport, fingerprint string) error {
+	payload := kite.PipelineSuccessPayload{
+		PipelineName:    s.pipelineIdentifier,
+		Namespace:       s.namespace,
+		Warnings:        len(report.Warnings),
+		Infos:           len(report.Infos),
+		Labels:          s.labels,
+		OutsideSchedule: report.Stats.OutsideSchedule > 0,
+		Fingerprint:     fingerprint,
+	}
+
+	return s.sendWebhook(ctx, "pipeline-success", payload)
+}
+
+func (s *kiteSink) sendHeartbeatWebhook(ctx context.Context, failed bool, report *doctor.SimpleReport, fingerprint string) error {
+	failures := 0
+	if failed {
+		failures = 1
+	}
+
+	payload := kite.CustomPayload{
+		PipelineId:  s.pipelineIdentifier,
+		Namespace:   s.namespace,
+		Repository:  s.repository,
+		Branch:      s.branch,
+		PipelineRun: s.runID,
+		Type:        "heartbeat",
+		Logs:        []string{},
+		Labels:      s.labels,
+		Fingerprint: fingerprint,
+		Stats: map[string]int{
+			"failed":           failures,
+			"errors":           len(report.Errors),
+			"warnings":         len(report.Warnings),
+			"infos":            len(report.Infos),
+			"schemaViolations": report.Stats.SchemaViolations,
+			"downgraded":       report.Stats.Downgraded,
+			"parseErrors":      report.Stats.ParseErrors,
+		},
+	}
+
+	return s.sendWebhook(ctx, "mintmaker-custom", payload)
+}
+
+// isDeadLetterWarning checks if a webhook error only needs a warning, since the webhook was written to the dead-letter file
+func (s *kiteSink) isDeadLetterWarning(err error) bool {
+	return s.deadLetterWarn && errors.Is(err, kite.ErrDeadLettered)
+}
+
+// sendRepositoryFailureWebhooks sends a failure webhook for each repository with failures,
+// failures logged outside of any repository are sent with the default pipeline identifier
+func (s *kiteSink) sendRepositoryFailureWebhooks(ctx context.Context, report *doctor.SimpleReport, fingerprint string) error {
+	var errs []error
+	for repository, failReason := range report.RepositoryFailures {
+		pipelineIdentifier := s.pipelineIdentifier
+		if repository != "" {
+			pipelineIdentifier = fmt.Sprintf("%s/%s@%s", s.gitHost, repository, s.branch)
+		}
+		if err := s.sendFailureWebhook(ctx, pipelineIdentifier, failReason, fingerprint); err != nil {
+			errs = append(errs, fmt.Errorf("repository %s: %w", pipelineIdentifier, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *kiteSink) sendFailureWebhook(ctx context.Context, pipelineIdentifier, failReason, fingerprint string) error {
+	payload := kite.PipelineFailurePayload{
+		PipelineName:  pipelineIdentifier,
+		Namespace:     s.namespace,
+		FailureReason: failReason,
+		RunID:         s.runID,
+		LogsURL:       s.logsURL,
+		Labels:        s.labels,
+		Fingerprint:   fingerprint,
+	}
+
+	return s.sendWebhook(ctx, "pipeline-failure", payload)
+}
+
+// sendWebhook marshals the payload and sends it to the given Kite webhook
+func (s *kiteSink) sendWebhook(ctx context.Context, webhookName string, payload any) error {
+	marshaledPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("unable to marshal payload: %w", err)
+	}
+
+	if err := s.client.SendWebhookRequest(ctx, s.namespace, webhookName, marshaledPayload); err != nil {
+		s.webhooksFailed++
+		return err
+	}
+	s.webhooksSent++
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/doctor"
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/kite"
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/output"
+)
+
+func TestKiteSinkHealthCheck(t *testing.T) {
+	tests := []struct {
+		status       string
+		wantSkipped  bool
+		wantWebhooks []string
+	}{
+		{status: "healthy", wantWebhooks: []string{"mintmaker-custom", "pipeline-failure"}},
+		{status: "degraded", wantWebhooks: []string{"mintmaker-custom", "pipeline-failure"}},
+		{status: "down", wantSkipped: true},
+		{status: "unavailable", wantSkipped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			var mu sync.Mutex
+			var webhooks []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/v1/health" {
+					fmt.Fprintf(w, `{"status":%q}`, tt.status)
+					return
+				}
+				mu.Lock()
+				webhooks = append(webhooks, strings.TrimPrefix(r.URL.Path, "/api/v1/webhooks/"))
+				mu.Unlock()
+				fmt.Fprint(w, `{}`)
+			}))
+			defer server.Close()
+
+			client, err := kite.NewClient(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sink := &kiteSink{
+				logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+				client:             client,
+				apiURL:             server.URL,
+				namespace:          "namespace-name",
+				pipelineIdentifier: "github.com/org/repo@main",
+			}
+			report := &doctor.SimpleReport{Errors: []string{"No go.mod found"}}
+			if err := sink.Emit(context.Background(), report, output.Verdict{FailReason: "Mintmaker finished with 1 ERROR"}); err != nil {
+				t.Fatalf("Emit() error = %v", err)
+			}
+
+			if sink.webhooksSkipped != tt.wantSkipped {
+				t.Errorf("webhooksSkipped = %v, want %v", sink.webhooksSkipped, tt.wantSkipped)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			slices.Sort(webhooks)
+			if !slices.Equal(webhooks, tt.wantWebhooks) {
+				t.Errorf("webhooks sent = %q, want %q", webhooks, tt.wantWebhooks)
+			}
+		})
+	}
+}
+
+func TestKiteSinkFingerprint(t *testing.T) {
+	var mu sync.Mutex
+	fingerprints := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/health" {
+			fmt.Fprint(w, `{"status":"healthy"}`)
+			return
+		}
+		var payload struct {
+			Type        string `json:"type"`
+			Fingerprint string `json:"fingerprint"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid webhook payload: %v", err)
+		}
+		webhook := strings.TrimPrefix(r.URL.Path, "/api/v1/webhooks/")
+		if payload.Type != "" {
+			webhook += "/" + payload.Type
+		}
+		mu.Lock()
+		fingerprints[webhook] = payload.Fingerprint
+		mu.Unlock()
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	client, err := kite.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &kiteSink{
+		logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client:             client,
+		apiURL:             server.URL,
+		namespace:          "namespace-name",
+		pipelineIdentifier: "github.com/org/repo@main",
+		sendHeartbeat:      true,
+	}
+	report := &doctor.SimpleReport{Warnings: []string{"PR limit reached - skipping PR creation"}}
+	if err := sink.Emit(context.Background(), report, output.Verdict{}); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, webhook := range []string{"pipeline-success", "mintmaker-custom/warning", "mintmaker-custom/heartbeat"} {
+		if fingerprints[webhook] != report.Fingerprint() {
+			t.Errorf("%s fingerprint = %q, want %q", webhook, fingerprints[webhook], report.Fingerprint())
+		}
+	}
+}
@@ -0,0 +1,537 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config holds the typed analyzer settings, loaded from their defaults, then a YAML or JSON file
+// and then the environment variables; the flags override them last
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/doctor"
+	"go.yaml.in/yaml/v3"
+)
+
+// Duration is a time.Duration written as a Go duration string, e.g. "1s", in the config file
+type Duration time.Duration
+
+// UnmarshalText parses the duration string
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// MarshalText formats the duration as a Go duration string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Config holds the analyzer settings, each one named after its environment variable
+type Config struct {
+	KiteAPIURL         string            `json:"kiteApiUrl" yaml:"kiteApiUrl"`
+	KiteAPIURLMap      map[string]string `json:"kiteApiUrlMap" yaml:"kiteApiUrlMap"`
+	KiteAPIURLMapFile  string            `json:"kiteApiUrlMapFile" yaml:"kiteApiUrlMapFile"`
+	KiteAPIToken       string            `json:"kiteApiToken" yaml:"kiteApiToken"`
+	KiteCAFile         string            `json:"kiteCaFile" yaml:"kiteCaFile"`
+	KiteRequestTimeout Duration          `json:"kiteRequestTimeout" yaml:"kiteRequestTimeout"`
+
+	Namespace   string `json:"namespace" yaml:"namespace"`
+	PipelineRun string `json:"pipelineRun" yaml:"pipelineRun"`
+	GitHost     string `json:"gitHost" yaml:"gitHost"`
+	Repository  string `json:"repository" yaml:"repository"`
+	Branch      string `json:"branch" yaml:"branch"`
+	LogsURL     string `json:"logsUrl" yaml:"logsUrl"`
+
+	LogFile             string   `json:"logFile" yaml:"logFile"`
+	LogFileWaitAttempts int      `json:"logFileWaitAttempts" yaml:"logFileWaitAttempts"`
+	LogFileWaitInterval Duration `json:"logFileWaitInterval" yaml:"logFileWaitInterval"`
+	LogFileStartOffset  int64    `json:"logFileStartOffset" yaml:"logFileStartOffset"`
+	MaxLogLineBytes     int      `json:"maxLogLineBytes" yaml:"maxLogLineBytes"`
+	MaxErrorLines       int      `json:"maxErrorLines" yaml:"maxErrorLines"`
+	Tail                bool     `json:"tail" yaml:"tail"`
+	TailUntil           string   `json:"tailUntil" yaml:"tailUntil"`
+	TailInterval        Duration `json:"tailInterval" yaml:"tailInterval"`
+	TailTimeout         Duration `json:"tailTimeout" yaml:"tailTimeout"`
+	ValidateLogSchema   bool     `json:"validateLogSchema" yaml:"validateLogSchema"`
+	IncludeLineNumbers  bool     `json:"includeLineNumbers" yaml:"includeLineNumbers"`
+	FilterBranch        string   `json:"filterBranch" yaml:"filterBranch"`
+
+	OptionalChecks           []string `json:"optionalChecks" yaml:"optionalChecks"`
+	NoisyDependencies        []string `json:"noisyDependencies" yaml:"noisyDependencies"`
+	WarningNormalizePatterns []string `json:"warningNormalizePatterns" yaml:"warningNormalizePatterns"`
+	CriticalPatterns         []string `json:"criticalPatterns" yaml:"criticalPatterns"`
+	CriticalPatternsFile     string   `json:"criticalPatternsFile" yaml:"criticalPatternsFile"`
+	RedactPatterns           []string `json:"redactPatterns" yaml:"redactPatterns"`
+	CorrelateErrors          bool     `json:"correlateErrors" yaml:"correlateErrors"`
+	GroupBranchErrors        bool     `json:"groupBranchErrors" yaml:"groupBranchErrors"`
+	Strict                   bool     `json:"strict" yaml:"strict"`
+	FailOn                   string   `json:"failOn" yaml:"failOn"`
+
+	WebhookLabels                map[string]string `json:"webhookLabels" yaml:"webhookLabels"`
+	CustomWebhookIncludeSelector bool              `json:"customWebhookIncludeSelector" yaml:"customWebhookIncludeSelector"`
+	CustomWebhookSeverities      []string          `json:"customWebhookSeverities" yaml:"customWebhookSeverities"`
+	SplitFailureWebhook          bool              `json:"splitFailureWebhook" yaml:"splitFailureWebhook"`
+	SendHeartbeat                bool              `json:"sendHeartbeat" yaml:"sendHeartbeat"`
+	DryRun                       bool              `json:"dryRun" yaml:"dryRun"`
+	DeadLetterFile               string            `json:"deadLetterFile" yaml:"deadLetterFile"`
+	DeadLetterVerdict            string            `json:"deadLetterVerdict" yaml:"deadLetterVerdict"`
+	BaselineReport               string            `json:"baselineReport" yaml:"baselineReport"`
+
+	StatsdAddr   string            `json:"statsdAddr" yaml:"statsdAddr"`
+	StatsdTags   map[string]string `json:"statsdTags" yaml:"statsdTags"`
+	StatsdPrefix string            `json:"statsdPrefix" yaml:"statsdPrefix"`
+	MetricsFile  string            `json:"metricsFile" yaml:"metricsFile"`
+	MetricsURL   string            `json:"metricsUrl" yaml:"metricsUrl"`
+	MetricsJob   string            `json:"metricsJob" yaml:"metricsJob"`
+	SyslogAddr   string            `json:"syslogAddr" yaml:"syslogAddr"`
+	SyslogOnly   bool              `json:"syslogOnly" yaml:"syslogOnly"`
+
+	Checks Checks `json:"checks" yaml:"checks"`
+}
+
+// Checks changes the built-in checks, the ones left out keep their default selector and severity
+type Checks struct {
+	Disabled   []string          `json:"disabled" yaml:"disabled"`     // built-in selectors turned off, also DISABLED_CHECKS
+	Severities map[string]string `json:"severities" yaml:"severities"` // severity of the entries of a built-in selector
+	Selectors  []Selector        `json:"selectors" yaml:"selectors"`   // selectors added to the built-in ones
+}
+
+// Selector is a check defined in the config file, reporting a fixed message for the matching log entries
+type Selector struct {
+	Selector string `json:"selector" yaml:"selector"`
+	Match    string `json:"match" yaml:"match"` // contains (the default), exact, prefix or regex
+	Severity string `json:"severity" yaml:"severity"`
+	Message  string `json:"message" yaml:"message"`
+	Hint     string `json:"hint" yaml:"hint"`
+}
+
+// Default returns the settings used when neither the config file nor the environment sets them
+func Default() *Config {
+	return &Config{
+		KiteRequestTimeout:      Duration(10 * time.Second),
+		PipelineRun:             "unknown",
+		GitHost:                 "unknown",
+		Repository:              "unknown",
+		Branch:                  "unknown",
+		LogFile:                 "/workspace/shared-data/renovate-logs.json",
+		LogFileWaitAttempts:     5,
+		LogFileWaitInterval:     Duration(time.Second),
+		MaxLogLineBytes:         1048576,
+		MaxErrorLines:           8,
+		TailUntil:               "Renovate exiting",
+		TailInterval:            Duration(time.Second),
+		FailOn:                  "none",
+		CustomWebhookSeverities: []string{"error", "warning", "info"},
+		DeadLetterVerdict:       "fail",
+		StatsdPrefix:            "renovate_log_analyzer.",
+		MetricsJob:              "renovate-log-analyzer",
+	}
+}
+
+// Load reads the config file on top of the defaults, a ".json" file is decoded as JSON and any other one
+// as YAML; unknown settings are rejected and all the invalid ones are reported at once
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := Default()
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		// an empty file keeps the defaults
+		if err = decoder.Decode(cfg); errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks all the settings, returning the errors of every invalid one joined together
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(name, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)))
+	}
+
+	if c.FailOn != "error" && c.FailOn != "warning" && c.FailOn != "none" {
+		invalid("failOn", "%q must be \"error\", \"warning\" or \"none\"", c.FailOn)
+	}
+	for _, severity := range c.CustomWebhookSeverities {
+		if severity != "error" && severity != "warning" && severity != "info" {
+			invalid("customWebhookSeverities", "%q must be \"error\", \"warning\" or \"info\"", severity)
+		}
+	}
+	if c.DeadLetterVerdict != "fail" && c.DeadLetterVerdict != "warn" {
+		invalid("deadLetterVerdict", "%q must be \"fail\" or \"warn\"", c.DeadLetterVerdict)
+	}
+
+	if c.LogFileWaitAttempts <= 0 {
+		invalid("logFileWaitAttempts", "%d must be positive", c.LogFileWaitAttempts)
+	}
+	if c.LogFileStartOffset < 0 {
+		invalid("logFileStartOffset", "%d must not be negative", c.LogFileStartOffset)
+	}
+	if c.MaxLogLineBytes <= 0 {
+		invalid("maxLogLineBytes", "%d must be positive", c.MaxLogLineBytes)
+	}
+	if c.MaxErrorLines <= 0 {
+		invalid("maxErrorLines", "%d must be positive", c.MaxErrorLines)
+	}
+
+	durations := []struct {
+		name  string
+		value Duration
+	}{
+		{"kiteRequestTimeout", c.KiteRequestTimeout},
+		{"logFileWaitInterval", c.LogFileWaitInterval},
+		{"tailInterval", c.TailInterval},
+		{"tailTimeout", c.TailTimeout},
+	}
+	for _, duration := range durations {
+		if duration.value < 0 {
+			invalid(duration.name, "%s must not be negative", time.Duration(duration.value))
+		}
+	}
+
+	patterns := []struct {
+		name   string
+		values []string
+	}{
+		{"warningNormalizePatterns", c.WarningNormalizePatterns},
+		{"criticalPatterns", c.CriticalPatterns},
+		{"redactPatterns", c.RedactPatterns},
+	}
+	for _, pattern := range patterns {
+		for _, value := range pattern.values {
+			if _, err := regexp.Compile(value); err != nil {
+				invalid(pattern.name, "%v", err)
+			}
+		}
+	}
+	// An empty regex would mask every message of the report
+	if slices.Contains(c.RedactPatterns, "") {
+		invalid("redactPatterns", "empty regex")
+	}
+
+	keyValues := []struct {
+		name   string
+		values map[string]string
+	}{
+		{"kiteApiUrlMap", c.KiteAPIURLMap},
+		{"webhookLabels", c.WebhookLabels},
+		{"statsdTags", c.StatsdTags},
+	}
+	for _, keyValue := range keyValues {
+		for key := range keyValue.values {
+			if key == "" || strings.ContainsAny(key, ",=") {
+				invalid(keyValue.name, "key %q must be non-empty without \",\" or \"=\"", key)
+			}
+		}
+	}
+
+	builtin := doctor.RegisteredSelectors()
+	for _, selector := range c.Checks.Disabled {
+		if _, found := builtin[selector]; !found {
+			invalid("checks.disabled", "unknown selector %q", selector)
+		}
+	}
+	for selector, severity := range c.Checks.Severities {
+		if _, found := builtin[selector]; !found {
+			invalid("checks.severities", "unknown selector %q", selector)
+		} else if _, err := doctor.WithSeverity(builtin[selector], severity); err != nil {
+			invalid("checks.severities", "selector %q: %v", selector, err)
+		}
+	}
+	for _, selector := range c.Checks.Selectors {
+		if _, _, err := selector.check(); err != nil {
+			invalid("checks.selectors", "%v", err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SelectorChecks returns the built-in selectors with the changes of the checks settings and the match modes
+// of the added ones, used as the selectors of the log processing
+func (c *Config) SelectorChecks() (map[string]doctor.CheckFunc, map[string]doctor.MatchMode, error) {
+	selectors := doctor.RegisteredSelectors()
+	for _, selector := range c.Checks.Disabled {
+		delete(selectors, selector)
+	}
+	for selector, severity := range c.Checks.Severities {
+		checkFunc, found := selectors[selector]
+		if !found {
+			continue
+		}
+		checkFunc, err := doctor.WithSeverity(checkFunc, severity)
+		if err != nil {
+			return nil, nil, fmt.Errorf("selector %q: %w", selector, err)
+		}
+		selectors[selector] = checkFunc
+	}
+
+	modes := make(map[string]doctor.MatchMode)
+	for _, selector := range c.Checks.Selectors {
+		checkFunc, mode, err := selector.check()
+		if err != nil {
+			return nil, nil, err
+		}
+		selectors[selector.Selector] = checkFunc
+		modes[selector.Selector] = mode
+	}
+	return selectors, modes, nil
+}
+
+// check returns the check function and the match mode of the selector
+func (s Selector) check() (doctor.CheckFunc, doctor.MatchMode, error) {
+	if s.Selector == "" {
+		return nil, 0, fmt.Errorf("missing selector")
+	}
+	mode, err := doctor.ParseMatchMode(s.Match)
+	if err != nil {
+		return nil, 0, fmt.Errorf("selector %q: %w", s.Selector, err)
+	}
+	if err := doctor.ValidateSelector(s.Selector, mode); err != nil {
+		return nil, 0, err
+	}
+	checkFunc, err := doctor.MessageCheck(s.Severity, s.Message, s.Hint)
+	if err != nil {
+		return nil, 0, fmt.Errorf("selector %q: %w", s.Selector, err)
+	}
+	return checkFunc, mode, nil
+}
+
+// ApplyEnv overrides the settings with the environment variables set to a non-empty value, lookupEnv is usually
+// os.LookupEnv; an empty LOG_FILE is kept to read the logs from a piped stdin. A bad MAX_ERROR_LINES only logs
+// a warning with the default slog logger and keeps the previous value. All the invalid values are reported at once.
+func (c *Config) ApplyEnv(lookupEnv func(string) (string, bool)) error {
+	var errs []error
+	env := func(name string, set func(string) error) {
+		if value, _ := lookupEnv(name); value != "" {
+			if err := set(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", name, err))
+			}
+		}
+	}
+
+	env("KITE_API_URL", setString(&c.KiteAPIURL))
+	env("KITE_API_URL_MAP", setKeyValues(&c.KiteAPIURLMap))
+	env("KITE_API_URL_MAP_FILE", setString(&c.KiteAPIURLMapFile))
+	env("KITE_API_TOKEN", setString(&c.KiteAPIToken))
+	env("KITE_CA_FILE", setString(&c.KiteCAFile))
+	env("KITE_REQUEST_TIMEOUT", setDuration(&c.KiteRequestTimeout))
+
+	env("NAMESPACE", setString(&c.Namespace))
+	env("PIPELINE_RUN", setString(&c.PipelineRun))
+	env("GIT_HOST", setString(&c.GitHost))
+	env("REPOSITORY", setString(&c.Repository))
+	env("BRANCH", setString(&c.Branch))
+	env("LOGS_URL", setString(&c.LogsURL))
+
+	if value, set := lookupEnv("LOG_FILE"); set {
+		c.LogFile = value
+	}
+	env("LOG_FILE_WAIT_ATTEMPTS", setInt(&c.LogFileWaitAttempts))
+	env("LOG_FILE_WAIT_INTERVAL", setDuration(&c.LogFileWaitInterval))
+	env("LOG_FILE_START_OFFSET", func(value string) (err error) {
+		c.LogFileStartOffset, err = strconv.ParseInt(value, 10, 64)
+		return err
+	})
+	env("MAX_LOG_LINE_BYTES", setInt(&c.MaxLogLineBytes))
+	// A bad line limit only makes the messages longer or shorter, it doesn't fail the run
+	if value, _ := lookupEnv("MAX_ERROR_LINES"); value != "" {
+		if lines, err := strconv.Atoi(value); err == nil && lines > 0 {
+			c.MaxErrorLines = lines
+		} else {
+			slog.Warn("Invalid MAX_ERROR_LINES, must be a positive integer, using the configured value",
+				"value", value, "maxErrorLines", c.MaxErrorLines)
+		}
+	}
+	env("TAIL", setBool(&c.Tail))
+	env("TAIL_UNTIL", setString(&c.TailUntil))
+	env("TAIL_INTERVAL", setDuration(&c.TailInterval))
+	env("TAIL_TIMEOUT", setDuration(&c.TailTimeout))
+	env("VALIDATE_LOG_SCHEMA", setBool(&c.ValidateLogSchema))
+	env("INCLUDE_LINE_NUMBERS", setBool(&c.IncludeLineNumbers))
+	env("FILTER_BRANCH", setString(&c.FilterBranch))
+
+	env("OPTIONAL_CHECKS", setList(&c.OptionalChecks))
+	env("NOISY_DEPENDENCIES", setList(&c.NoisyDependencies))
+	env("WARNING_NORMALIZE_PATTERNS", setPatterns(&c.WarningNormalizePatterns))
+	env("CRITICAL_PATTERNS", setPatterns(&c.CriticalPatterns))
+	env("CRITICAL_PATTERNS_FILE", setString(&c.CriticalPatternsFile))
+	env("REDACT_PATTERNS", setPatterns(&c.RedactPatterns))
+	env("CORRELATE_ERRORS", setBool(&c.CorrelateErrors))
+	env("GROUP_BRANCH_ERRORS", setBool(&c.GroupBranchErrors))
+	env("STRICT", setBool(&c.Strict))
+	env("FAIL_ON", setString(&c.FailOn))
+	env("DISABLED_CHECKS", setList(&c.Checks.Disabled))
+
+	env("WEBHOOK_LABELS", setKeyValues(&c.WebhookLabels))
+	env("CUSTOM_WEBHOOK_INCLUDE_SELECTOR", setBool(&c.CustomWebhookIncludeSelector))
+	env("CUSTOM_WEBHOOK_SEVERITIES", setList(&c.CustomWebhookSeverities))
+	env("SPLIT_FAILURE_WEBHOOK", setBool(&c.SplitFailureWebhook))
+	env("SEND_HEARTBEAT", setBool(&c.SendHeartbeat))
+	env("DRY_RUN", setBool(&c.DryRun))
+	env("DEAD_LETTER_FILE", setString(&c.DeadLetterFile))
+	env("DEAD_LETTER_VERDICT", setString(&c.DeadLetterVerdict))
+	env("BASELINE_REPORT", setString(&c.BaselineReport))
+
+	env("STATSD_ADDR", setString(&c.StatsdAddr))
+	env("STATSD_TAGS", setKeyValues(&c.StatsdTags))
+	env("STATSD_PREFIX", setString(&c.StatsdPrefix))
+	env("METRICS_FILE", setString(&c.MetricsFile))
+	env("METRICS_URL", setString(&c.MetricsURL))
+	env("METRICS_JOB", setString(&c.MetricsJob))
+	env("SYSLOG_ADDR", setString(&c.SyslogAddr))
+	env("SYSLOG_ONLY", setBool(&c.SyslogOnly))
+
+	return errors.Join(errs...)
+}
+
+func setString(field *string) func(string) error {
+	return func(value string) error {
+		*field = value
+		return nil
+	}
+}
+
+// setBool sets the field to the boolean value, e.g. "true", "1" or "false"
+func setBool(field *bool) func(string) error {
+	return func(value string) (err error) {
+		*field, err = strconv.ParseBool(value)
+		return err
+	}
+}
+
+func setInt(field *int) func(string) error {
+	return func(value string) (err error) {
+		*field, err = strconv.Atoi(value)
+		return err
+	}
+}
+
+func setDuration(field *Duration) func(string) error {
+	return func(value string) error {
+		return field.UnmarshalText([]byte(value))
+	}
+}
+
+// setList sets the field to the comma-separated values, trimmed of spaces
+func setList(field *[]string) func(string) error {
+	return func(value string) error {
+		*field = nil
+		for _, item := range strings.Split(value, ",") {
+			*field = append(*field, strings.TrimSpace(item))
+		}
+		return nil
+	}
+}
+
+// setPatterns sets the field to the comma-separated regexes, kept as is since spaces can be part of them.
+// The regexes are checked right away, a regex split in the wrong place may still compile and match nothing
+func setPatterns(field *[]string) func(string) error {
+	return func(value string) error {
+		patterns := splitPatterns(value)
+		for _, pattern := range patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return err
+			}
+		}
+		*field = patterns
+		return nil
+	}
+}
+
+// splitPatterns splits the regexes on newlines if there are any, otherwise on the commas outside of
+// repetitions like {1,3} and character classes like [,;], an escaped comma \, is part of the regex
+func splitPatterns(value string) []string {
+	if strings.Contains(value, "\n") {
+		var patterns []string
+		for _, pattern := range strings.Split(value, "\n") {
+			if pattern = strings.TrimSuffix(pattern, "\r"); pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+		return patterns
+	}
+
+	var patterns []string
+	start, escaped, inClass, inRepeat := 0, false, false, false
+	for i, char := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case inClass:
+			inClass = char != ']'
+		case char == '[':
+			inClass = true
+		case char == '{':
+			inRepeat = true
+		case char == '}':
+			inRepeat = false
+		case char == ',' && !inRepeat:
+			patterns = append(patterns, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(patterns, value[start:])
+}
+
+// setKeyValues sets the field to the comma-separated list of key=value pairs
+func setKeyValues(field *map[string]string) func(string) error {
+	return func(value string) error {
+		values := make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if !found || key == "" {
+				return fmt.Errorf("%q is not in the key=value format", pair)
+			}
+			values[key] = val
+		}
+		*field = values
+		return nil
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/doctor"
+)
+
+// lookupEnv returns a lookup of the given environment variables
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, set := env[name]
+		return value, set
+	}
+}
+
+// loadFile loads the given YAML config file content
+func loadFile(t *testing.T, content string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return cfg
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		env        map[string]string
+		check      func(*Config) bool
+		wantErrors bool
+	}{
+		{
+			name:  "default without file or env",
+			check: func(c *Config) bool { return c.FailOn == "none" && !c.Strict },
+		},
+		{
+			name:  "file overrides default",
+			file:  "strict: true\nfailOn: error\n",
+			check: func(c *Config) bool { return c.Strict && c.FailOn == "error" },
+		},
+		{
+			name:  "env overrides file",
+			file:  "strict: true\nfailOn: error\n",
+			env:   map[string]string{"STRICT": "false", "FAIL_ON": "warning"},
+			check: func(c *Config) bool { return !c.Strict && c.FailOn == "warning" },
+		},
+		{
+			name:  "empty env keeps file",
+			file:  "failOn: error\n",
+			env:   map[string]string{"FAIL_ON": ""},
+			check: func(c *Config) bool { return c.FailOn == "error" },
+		},
+		{
+			name:  "explicit empty list in file",
+			file:  "customWebhookSeverities: []\n",
+			check: func(c *Config) bool { return c.CustomWebhookSeverities != nil && len(c.CustomWebhookSeverities) == 0 },
+		},
+		{
+			name: "list from env",
+			env:  map[string]string{"CUSTOM_WEBHOOK_SEVERITIES": "error, warning"},
+			check: func(c *Config) bool {
+				return slices.Equal(c.CustomWebhookSeverities, []string{"error", "warning"})
+			},
+		},
+		{
+			name:  "empty LOG_FILE clears file",
+			file:  "logFile: /tmp/renovate.json\n",
+			env:   map[string]string{"LOG_FILE": ""},
+			check: func(c *Config) bool { return c.LogFile == "" },
+		},
+		{
+			name: "key=value pairs from env",
+			env:  map[string]string{"WEBHOOK_LABELS": "team=mintmaker,env=prod"},
+			check: func(c *Config) bool {
+				return c.WebhookLabels["team"] == "mintmaker" && c.WebhookLabels["env"] == "prod"
+			},
+		},
+		{
+			name: "disabled checks from env",
+			file: "checks:\n  disabled: [\"GraphQL\"]\n",
+			env:  map[string]string{"DISABLED_CHECKS": "is too long, heap usage"},
+			check: func(c *Config) bool {
+				return slices.Equal(c.Checks.Disabled, []string{"is too long", "heap usage"})
+			},
+		},
+		{
+			name:  "boolean values",
+			env:   map[string]string{"INCLUDE_LINE_NUMBERS": "1", "STRICT": "True", "TAIL": "f"},
+			check: func(c *Config) bool { return c.IncludeLineNumbers && c.Strict && !c.Tail },
+		},
+		{
+			name:       "invalid boolean",
+			env:        map[string]string{"INCLUDE_LINE_NUMBERS": "yes"},
+			wantErrors: true,
+		},
+		{
+			name:       "invalid values",
+			env:        map[string]string{"LOG_FILE_WAIT_ATTEMPTS": "five", "TAIL_TIMEOUT": "soon", "WEBHOOK_LABELS": "team"},
+			wantErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			if tt.file != "" {
+				cfg = loadFile(t, tt.file)
+			}
+			err := cfg.ApplyEnv(lookupEnv(tt.env))
+			if (err != nil) != tt.wantErrors {
+				t.Fatalf("ApplyEnv() error = %v, want errors %v", err, tt.wantErrors)
+			}
+			if tt.check != nil && !tt.check(cfg) {
+				t.Errorf("unexpected settings %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestSelectorChecks(t *testing.T) {
+	cfg := loadFile(t, `checks:
+  disabled: ["GraphQL"]
+  severities:
+    "Reached PR limit - skipping PR creation": error
+  selectors:
+    - selector: "^Internal registry quota .* reached$"
+      match: regex
+      severity: warning
+      message: Internal registry quota reached
+`)
+	selectors, modes, err := cfg.SelectorChecks()
+	if err != nil {
+		t.Fatalf("SelectorChecks() error = %v", err)
+	}
+	if _, found := selectors["GraphQL"]; found {
+		t.Error("disabled selector GraphQL is still checked")
+	}
+	if _, found := selectors["rawExec err"]; !found {
+		t.Error("built-in selector rawExec err is missing")
+	}
+	if modes["^Internal registry quota .* reached$"] != doctor.MatchRegex {
+		t.Error("added selector isn't matched as a regex")
+	}
+
+	_, report, err := doctor.ProcessLogReader(context.Background(), strings.NewReader(
+		`{"level":30,"msg":"Reached PR limit - skipping PR creation"}`+"\n"+
+			`{"level":40,"msg":"GraphQL response contains errors","err":{"message":"GraphQL response contains errors"}}`+"\n"+
+			`{"level":30,"msg":"Internal registry quota of team-a reached"}`+"\n"),
+		doctor.Options{Selectors: selectors, SelectorModes: modes})
+	if err != nil {
+		t.Fatalf("ProcessLogReader() error = %v", err)
+	}
+	if len(report.Errors) != 1 || !strings.HasPrefix(report.Errors[0], "PR limit reached") {
+		t.Errorf("report errors = %q, want the PR limit warning as an error", report.Errors)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0] != "Internal registry quota reached" {
+		t.Errorf("report warnings = %q, want the added selector warning and no GraphQL warning", report.Warnings)
+	}
+}
+
+func TestValidateChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks Checks
+	}{
+		{name: "unknown disabled selector", checks: Checks{Disabled: []string{"no such selector"}}},
+		{name: "unknown severity selector", checks: Checks{Severities: map[string]string{"no such selector": "error"}}},
+		{name: "invalid severity", checks: Checks{Severities: map[string]string{"GraphQL": "fatal"}}},
+		{name: "invalid regex", checks: Checks{Selectors: []Selector{{Selector: "(", Match: "regex", Severity: "error", Message: "x"}}}},
+		{name: "unknown match mode", checks: Checks{Selectors: []Selector{{Selector: "x", Match: "glob", Severity: "error", Message: "x"}}}},
+		{name: "missing message", checks: Checks{Selectors: []Selector{{Selector: "x", Severity: "error"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Checks = tt.checks
+			if err := cfg.Validate(); err == nil {
+				t.Error("Validate() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestPatternsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "comma-separated", value: `(?i)squid,ERR_PROXY`, want: []string{`(?i)squid`, `ERR_PROXY`}},
+		{name: "repetition", value: `timeout after \d{1,3}s,ERR_PROXY`, want: []string{`timeout after \d{1,3}s`, `ERR_PROXY`}},
+		{name: "character class", value: `proxy[,;] refused,ERR_PROXY`, want: []string{`proxy[,;] refused`, `ERR_PROXY`}},
+		{name: "escaped comma", value: `refused\, retrying,ERR_PROXY`, want: []string{`refused\, retrying`, `ERR_PROXY`}},
+		{name: "newline-separated", value: "timeout, retrying\n\\d{1,3}s\n", want: []string{`timeout, retrying`, `\d{1,3}s`}},
+		{name: "invalid regex", value: `ERR_PROXY,(squid`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			err := cfg.ApplyEnv(lookupEnv(map[string]string{"CRITICAL_PATTERNS": tt.value}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(cfg.CriticalPatterns, tt.want) {
+				t.Errorf("critical patterns = %q, want %q", cfg.CriticalPatterns, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactPatternsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "repetition", value: `[A-Za-z0-9]{20,40}`, want: []string{`[A-Za-z0-9]{20,40}`}},
+		{name: "repetitions", value: `mysecret-[a-z0-9]{8,},token_[A-Z]{4,6}`, want: []string{`mysecret-[a-z0-9]{8,}`, `token_[A-Z]{4,6}`}},
+		{name: "invalid regex", value: `mysecret-[a-z0-9+`, wantErr: true},
+		// An empty regex would mask every message
+		{name: "trailing comma", value: `mysecret-[a-z0-9]+,`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			err := cfg.ApplyEnv(lookupEnv(map[string]string{"REDACT_PATTERNS": tt.value}))
+			if err == nil {
+				err = cfg.Validate()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyEnv() and Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(cfg.RedactPatterns, tt.want) {
+				t.Errorf("redact patterns = %q, want %q", cfg.RedactPatterns, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarningNormalizePatternsFromEnv(t *testing.T) {
+	cfg := Default()
+	if err := cfg.ApplyEnv(lookupEnv(map[string]string{"WARNING_NORMALIZE_PATTERNS": `\d{2,4}ms,retry \d+`})); err != nil {
+		t.Fatalf("ApplyEnv() error = %v", err)
+	}
+	if want := []string{`\d{2,4}ms`, `retry \d+`}; !slices.Equal(cfg.WarningNormalizePatterns, want) {
+		t.Fatalf("warning patterns = %q, want %q", cfg.WarningNormalizePatterns, want)
+	}
+
+	selectors := map[string]doctor.CheckFunc{
+		"timed out": func(line *doctor.LogEntry, report *doctor.SimpleReport) {
+			report.Warning("Request timed out", "Message", line.Msg)
+		},
+	}
+	_, report, err := doctor.ProcessLogReader(context.Background(), strings.NewReader(
+		`{"level":40,"msg":"timed out after 412ms, retry 1"}`+"\n"+
+			`{"level":40,"msg":"timed out after 3987ms, retry 2"}`+"\n"+
+			`{"level":40,"msg":"timed out after 30s, retry 3"}`+"\n"),
+		doctor.Options{Selectors: selectors, WarningPatterns: cfg.WarningNormalizePatterns})
+	if err != nil {
+		t.Fatalf("ProcessLogReader() error = %v", err)
+	}
+	// The durations in milliseconds are collapsed, the one in seconds isn't matched
+	if len(report.Warnings) != 2 || !strings.Contains(report.Warnings[0], "412ms, retry 1") ||
+		!strings.Contains(report.Warnings[1], "30s, retry 3") {
+		t.Errorf("report warnings = %q, want the first timeout in milliseconds and the one in seconds", report.Warnings)
+	}
+}
+
+func TestMaxErrorLinesFromEnv(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	tests := []struct {
+		name    string
+		file    string
+		value   string
+		want    int
+		wantLog bool
+	}{
+		{name: "default", want: 8},
+		{name: "from env", value: "12", want: 12},
+		{name: "env overrides file", file: "maxErrorLines: 20\n", value: "12", want: 12},
+		// A bad value keeps the lower precedence setting without failing
+		{name: "invalid keeps default", value: "many", want: 8, wantLog: true},
+		{name: "zero keeps file", file: "maxErrorLines: 20\n", value: "0", want: 20, wantLog: true},
+		{name: "negative keeps file", file: "maxErrorLines: 20\n", value: "-3", want: 20, wantLog: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			cfg := Default()
+			if tt.file != "" {
+				cfg = loadFile(t, tt.file)
+			}
+			if err := cfg.ApplyEnv(lookupEnv(map[string]string{"MAX_ERROR_LINES": tt.value})); err != nil {
+				t.Fatalf("ApplyEnv() error = %v", err)
+			}
+			if cfg.MaxErrorLines != tt.want {
+				t.Errorf("max error lines = %d, want %d", cfg.MaxErrorLines, tt.want)
+			}
+			if got := strings.Contains(logs.String(), "Invalid MAX_ERROR_LINES"); got != tt.wantLog {
+				t.Errorf("logs = %q, want the invalid value warning %v", logs.String(), tt.wantLog)
+			}
+		})
+	}
+}
@@ -16,16 +16,82 @@ package doctor
 
 import (
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // CheckFunc is a function that performs a check on a log line
 type CheckFunc func(line *LogEntry, report *SimpleReport)
 
-// Selectors stores all registered selector patterns and their associated check functions
+// Selectors stores all registered selector patterns and their associated check functions,
+// use RegisterSelector and RegisteredSelectors to change and read it while logs may be processed
 var Selectors = make(map[string]CheckFunc)
 
+// MatchMode is how a selector is matched against the message of a log entry
+type MatchMode int
+
+const (
+	MatchContains MatchMode = iota // the message contains the selector, the default
+	MatchExact                     // the message is the selector
+	MatchPrefix                    // the message starts with the selector
+	MatchRegex                     // the selector is a regex matching the message
+)
+
+// selectorModes stores the match modes of the selectors not matched as substrings
+var selectorModes = make(map[string]MatchMode)
+
+// selectorsMu guards Selectors and selectorModes against concurrent registrations and log processing
+var selectorsMu sync.RWMutex
+
+// optionalCheck is an opt-in check made of selectors and a summary reported once the whole log was processed
+type optionalCheck struct {
+	selectors map[string]CheckFunc
+	summary   func(report *SimpleReport)
+}
+
+// optionalChecks stores the opt-in checks by the name used to enable them
+var optionalChecks = map[string]optionalCheck{
+	"pr-summary": {
+		selectors: map[string]CheckFunc{
+			"PR created": prCreated,
+			"PR updated": prUpdated,
+		},
+		summary: summarizePRs,
+	},
+	"changelog-failures": {
+		selectors: map[string]CheckFunc{
+			"Error fetching changelog": changelogFetchFailure,
+		},
+		summary: summarizeChangelogFailures,
+	},
+	"proposed-updates": {
+		selectors: map[string]CheckFunc{
+			"Found update": proposedUpdate,
+		},
+	},
+	// Replaces the default memory pressure checks to report them as warnings
+	"memory-warnings": {
+		selectors: map[string]CheckFunc{
+			"High memory usage": memoryPressureWarning,
+			"heap usage":        memoryPressureWarning,
+		},
+	},
+	// Replaces the default platform API deprecation checks to report them as warnings
+	"deprecation-warnings": {
+		selectors: map[string]CheckFunc{
+			"API deprecation": platformDeprecationWarning,
+			"deprecated API":  platformDeprecationWarning,
+		},
+	},
+}
+
 // CriticalPatterns contains compiled regex patterns for identifying critical error lines
 var criticalPatterns = []*regexp.Regexp{
 	regexp.MustCompile(`(?i)^\s*Command failed:`),
@@ -39,17 +105,234 @@ var criticalPatterns = []*regexp.Regexp{
 	regexp.MustCompile(`(?i)timed out`),
 }
 
-// RegisterSelector registers a selector pattern with its associated check function
-func registerSelector(selector string, checkFunc CheckFunc) {
+// AddCriticalPatterns compiles the regexes and adds them to the built-in critical patterns,
+// nothing is added if any of them is invalid
+func AddCriticalPatterns(patterns []string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid critical pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	criticalPatterns = append(criticalPatterns, compiled...)
+	return nil
+}
+
+// RegisterSelector registers a selector pattern with its associated check function, replacing the check
+// of an already registered selector, e.g. to add organization-specific checks next to the built-in ones
+func RegisterSelector(selector string, checkFunc CheckFunc) {
+	selectorsMu.Lock()
+	defer selectorsMu.Unlock()
+	Selectors[selector] = checkFunc
+	delete(selectorModes, selector)
+}
+
+// RegisterSelectorMatch registers a selector matched with the given mode instead of as a substring,
+// e.g. MatchExact so that longer unrelated messages containing the selector don't trigger the check
+func RegisterSelectorMatch(selector string, mode MatchMode, checkFunc CheckFunc) error {
+	if _, err := selectorMatcher(selector, mode); err != nil {
+		return err
+	}
+
+	selectorsMu.Lock()
+	defer selectorsMu.Unlock()
 	Selectors[selector] = checkFunc
+	if mode == MatchContains {
+		delete(selectorModes, selector)
+	} else {
+		selectorModes[selector] = mode
+	}
+	return nil
+}
+
+// RegisteredSelectors returns a copy of the registered selectors, e.g. to build a custom selector set on the built-in one
+func RegisteredSelectors() map[string]CheckFunc {
+	selectorsMu.RLock()
+	defer selectorsMu.RUnlock()
+	return maps.Clone(Selectors)
+}
+
+// selectorMatcher returns the function matching the messages of the selector with the mode
+func selectorMatcher(selector string, mode MatchMode) (func(msg string) bool, error) {
+	switch mode {
+	case MatchContains:
+		return func(msg string) bool { return strings.Contains(msg, selector) }, nil
+	case MatchExact:
+		return func(msg string) bool { return msg == selector }, nil
+	case MatchPrefix:
+		return func(msg string) bool { return strings.HasPrefix(msg, selector) }, nil
+	case MatchRegex:
+		re, err := regexp.Compile(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex selector %q: %w", selector, err)
+		}
+		return re.MatchString, nil
+	default:
+		return nil, fmt.Errorf("unknown match mode %d of selector %q", mode, selector)
+	}
+}
+
+// ValidateSelector checks that the selector can be matched with the mode, i.e. that a regex selector compiles
+func ValidateSelector(selector string, mode MatchMode) error {
+	_, err := selectorMatcher(selector, mode)
+	return err
+}
+
+// selectorMatchers returns the matcher of each selector, using the given modes over the registered ones
+func selectorMatchers(selectors map[string]CheckFunc, modes map[string]MatchMode) (map[string]func(msg string) bool, error) {
+	selectorsMu.RLock()
+	registeredModes := maps.Clone(selectorModes)
+	selectorsMu.RUnlock()
+	maps.Copy(registeredModes, modes)
+
+	matchers := make(map[string]func(msg string) bool, len(selectors))
+	for selector := range selectors {
+		matcher, err := selectorMatcher(selector, registeredModes[selector])
+		if err != nil {
+			return nil, err
+		}
+		matchers[selector] = matcher
+	}
+	return matchers, nil
+}
+
+// matchModes are the match modes by the name used in config files
+var matchModes = map[string]MatchMode{
+	"contains": MatchContains,
+	"exact":    MatchExact,
+	"prefix":   MatchPrefix,
+	"regex":    MatchRegex,
+}
+
+// ParseMatchMode returns the match mode named "contains", "exact", "prefix" or "regex", an empty name is MatchContains
+func ParseMatchMode(name string) (MatchMode, error) {
+	if name == "" {
+		return MatchContains, nil
+	}
+	mode, found := matchModes[name]
+	if !found {
+		return 0, fmt.Errorf("unknown match mode %q: must be \"contains\", \"exact\", \"prefix\" or \"regex\"", name)
+	}
+	return mode, nil
+}
+
+// reportFunc returns the report method of the severity, "error", "warning" or "info"
+func reportFunc(report *SimpleReport, severity string) func(msg string, fields ...interface{}) {
+	switch severity {
+	case "error":
+		return report.Error
+	case "warning":
+		return report.Warning
+	default:
+		return report.Info
+	}
+}
+
+// validSeverity checks that the severity is "error", "warning" or "info"
+func validSeverity(severity string) error {
+	if severity != "error" && severity != "warning" && severity != "info" {
+		return fmt.Errorf("invalid severity %q: must be \"error\", \"warning\" or \"info\"", severity)
+	}
+	return nil
+}
+
+// WithSeverity returns the check reporting its entries with the severity, "error", "warning" or "info",
+// e.g. to report a built-in warning as an error
+func WithSeverity(checkFunc CheckFunc, severity string) (CheckFunc, error) {
+	if err := validSeverity(severity); err != nil {
+		return nil, err
+	}
+	return func(line *LogEntry, report *SimpleReport) {
+		errorCount, warningCount, infoCount := len(report.Errors), len(report.Warnings), len(report.Infos)
+		checkFunc(line, report)
+
+		// The new entries are moved to the severity, skipping the ones it already has
+		reported := slices.Concat(report.Errors[errorCount:], report.Warnings[warningCount:], report.Infos[infoCount:])
+		report.Errors, report.Warnings, report.Infos = report.Errors[:errorCount], report.Warnings[:warningCount], report.Infos[:infoCount]
+		target, normalize := &report.Infos, func(formatted string) string { return formatted }
+		switch severity {
+		case "error":
+			target = &report.Errors
+		case "warning":
+			target, normalize = &report.Warnings, report.normalizeWarning
+		}
+		for _, formatted := range reported {
+			if !hasMessage(*target, lineFieldRe.ReplaceAllString(formatted, ""), normalize) {
+				*target = append(*target, formatted)
+			}
+		}
+	}, nil
+}
+
+// MessageCheck returns a check reporting the message with the severity, "error", "warning" or "info",
+// and the optional hint, e.g. for the selectors of a config file
+func MessageCheck(severity, message, hint string) (CheckFunc, error) {
+	if err := validSeverity(severity); err != nil {
+		return nil, err
+	}
+	if message == "" {
+		return nil, fmt.Errorf("missing message")
+	}
+	return func(line *LogEntry, report *SimpleReport) {
+		fields := appendExtra(nil, line, "Branch", "branch")
+		if hint != "" {
+			fields = append(fields, "Hint", hint)
+		}
+		reportFunc(report, severity)(message, fields...)
+	}, nil
 }
 
 func init() {
 	// Register all selectors
-	registerSelector("Reached PR limit - skipping PR creation", prLimitReached)
-	registerSelector("Found renovate config errors", renovateConfigErrors)
-	registerSelector("rawExec err", rawExecError)
-	registerSelector("Platform-native commit: unknown error", platformCommitError)
+	RegisterSelector("Reached PR limit - skipping PR creation", prLimitReached)
+	RegisterSelector("Found renovate config errors", renovateConfigErrors)
+	RegisterSelector("rawExec err", rawExecError)
+	RegisterSelector("Platform-native commit: unknown error", platformCommitError)
+	RegisterSelector("enabledManagers", noEnabledManagers)
+	RegisterSelector("Package lookup failures", packageLookupFailures)
+	RegisterSelector("GraphQL", graphqlError)
+	RegisterSelector("is too long", lengthLimitExceeded)
+	RegisterSelector("Filtered file list", pathFiltersMatchNothing)
+	RegisterSelector("unexpected file changes", unexpectedFileChanges)
+	RegisterSelector("Error committing files", commitFilesError)
+	RegisterSelector("statusCode=429", registryThrottled)
+	RegisterSelector("Error deleting orphan branch", staleBranchCleanupFailure)
+	RegisterSelector("Error mapping git submodules", submoduleUpdateFailure)
+	RegisterSelector("No fixed version available for vulnerability", vulnerabilityFixUnavailable)
+	RegisterSelector("API deprecation", platformDeprecation)
+	RegisterSelector("deprecated API", platformDeprecation)
+	RegisterSelector("packageRules with no matches", unmatchedPackageRules)
+	RegisterSelector("Error writing cache", cacheWriteFailure)
+	RegisterSelector("Error writing repository cache", cacheWriteFailure)
+	RegisterSelector("Failed to automerge PR", branchProtectionRejection)
+	RegisterSelector("(parsing failed)", configSyntaxError)
+	RegisterSelector("Error extracting", customManagerExtractionFailure)
+	RegisterSelector("clone error", repositoryCloneFailure)
+	RegisterSelector("not within schedule", outsideSchedule)
+	RegisterSelector("Failed to look up npm package", npmPrivateRegistryFallthrough)
+	RegisterSelector("High memory usage", memoryPressure)
+	RegisterSelector("heap usage", memoryPressure)
+	RegisterSelector("code E401", npmRegistryAuthError)
+	RegisterSelector("Response code 401", npmRegistryAuthError)
+	RegisterSelector("No go.mod found", missingManifest)
+	RegisterSelector("lockfile not found", missingManifest)
+	RegisterSelector("lock file not found", missingManifest)
+	RegisterSelector("manifest not found", missingManifest)
+	RegisterSelector("rate limit exceeded", platformRateLimit)
+	RegisterSelector("Rate limit exceeded", platformRateLimit)
+	RegisterSelector("secondary rate limit", platformRateLimit)
+	RegisterSelector("Error updating branch", branchUpdateConflict)
+	RegisterSelector("Could not resolve dependencies", artifactResolutionFailure)
+	RegisterSelector("Could not find artifact", artifactResolutionFailure)
+	RegisterSelector("branches info extended", branchesSummary)
+	RegisterSelector("ENOTFOUND", hostUnreachable)
+	RegisterSelector("EAI_AGAIN", hostUnreachable)
+	RegisterSelector("ECONNREFUSED", hostUnreachable)
+	RegisterSelector("ENETUNREACH", hostUnreachable)
+	RegisterSelector("EHOSTUNREACH", hostUnreachable)
+	RegisterSelector("Could not resolve host", hostUnreachable)
 }
 
 // extractUsefulError extracts the most useful parts of a potentially long error message.
@@ -85,6 +368,9 @@ func processLongMessage(lines []string, maxOutputLines int) string {
 	// Pattern to match lines with only symbols like ~^=
 	symbolPattern := regexp.MustCompile(`^\s*[~^=]+\s*$`)
 
+	// The exception of a Python traceback and its nearest frame are always kept
+	pinnedLines := pythonTracebackLines(lines)
+
 	for i, line := range lines[1:] { // skip first line, already added
 		i = i + 1 // adjust index because of slicing
 		trimmedLine := strings.TrimSpace(line)
@@ -108,9 +394,17 @@ func processLongMessage(lines []string, maxOutputLines int) string {
 		// Check if we should break and add the last few lines
 		if len(usefulLines) >= maxOutputLines {
 			omittedLines = cutLinesCount + len(lines) - i - 2 // count the remaining lines except last 3, which we always add
+			var pinned []string
+			for _, index := range pinnedLines {
+				if index >= i && index < len(lines)-3 {
+					pinned = append(pinned, strings.TrimSpace(lines[index]))
+				}
+			}
+			omittedLines -= len(pinned)
 			if omittedLines > 0 {
 				usefulLines = append(usefulLines, fmt.Sprintf("[... %d lines omitted ...]", omittedLines))
 			}
+			usefulLines = append(usefulLines, pinned...)
 
 			// Add the last few lines (very last line is empty after split)
 			if i <= len(lines)-3 {
@@ -133,7 +427,7 @@ func processLongMessage(lines []string, maxOutputLines int) string {
 		}
 
 		// Check if this line matches any critical pattern
-		if isCriticalLine(trimmedLine) {
+		if isCriticalLine(trimmedLine) || slices.Contains(pinnedLines, i) {
 			// Add any buffered context lines if we have cut lines
 			omittedLines = cutLinesCount - len(contextBuffer)
 			if omittedLines > 0 {
@@ -157,6 +451,37 @@ func processLongMessage(lines []string, maxOutputLines int) string {
 	return strings.Join(usefulLines, "\n")
 }
 
+var pythonTracebackRe = regexp.MustCompile(`^\s*Traceback \(most recent call last\):`)
+var pythonFrameRe = regexp.MustCompile(`^\s*File "[^"]*", line \d+`)
+var pythonExceptionRe = regexp.MustCompile(`^\s*[A-Za-z_][\w.]*(Error|Exception|Exit|Interrupt)(:|$)`)
+
+// pythonTracebackLines returns the indexes of the nearest frame and the exception line of the last
+// Python traceback in the lines, or nothing without a traceback
+func pythonTracebackLines(lines []string) []int {
+	header := -1
+	for i, line := range lines {
+		if pythonTracebackRe.MatchString(line) {
+			header = i
+		}
+	}
+	if header < 0 {
+		return nil
+	}
+
+	frame := -1
+	for i := header + 1; i < len(lines); i++ {
+		if pythonFrameRe.MatchString(lines[i]) {
+			frame = i
+		} else if frame >= 0 && pythonExceptionRe.MatchString(lines[i]) {
+			return []int{frame, i}
+		}
+	}
+	if frame < 0 {
+		return nil
+	}
+	return []int{frame}
+}
+
 // isCriticalLine checks if a line matches any critical error pattern
 func isCriticalLine(line string) bool {
 	for _, pattern := range criticalPatterns {
@@ -167,9 +492,35 @@ func isCriticalLine(line string) bool {
 	return false
 }
 
-// Default version with maxOutputLines=8
-func extractUsefulErrorDefault(fullMessage string) string {
-	return extractUsefulError(fullMessage, 8)
+// appendExtra appends the extra field of the log entry to the report fields under the given key,
+// fields missing from the log entry are skipped
+func appendExtra(fields []interface{}, line *LogEntry, key, extra string) []interface{} {
+	if value, ok := line.Extras[extra]; ok && value != nil {
+		return append(fields, key, value)
+	}
+	return fields
+}
+
+// isWarningOrAbove checks if the log entry was logged as a warning or above, debug and info entries
+// mentioning a failure are often retries or progress that Renovate recovers from
+func isWarningOrAbove(line *LogEntry) bool {
+	switch line.Level {
+	case "WARN", "ERROR", "FATAL":
+		return true
+	}
+	return false
+}
+
+// defaultMaxErrorLines is the number of lines kept of long error messages unless configured otherwise
+const defaultMaxErrorLines = 8
+
+// extractReportError extracts the most useful parts of an error message, up to the line limit of the report
+func extractReportError(fullMessage string, report *SimpleReport) string {
+	maxOutputLines := report.maxErrorLines
+	if maxOutputLines <= 0 {
+		maxOutputLines = defaultMaxErrorLines
+	}
+	return extractUsefulError(fullMessage, maxOutputLines)
 }
 
 func prLimitReached(line *LogEntry, report *SimpleReport) {
@@ -210,6 +561,47 @@ func rawExecError(line *LogEntry, report *SimpleReport) {
 
 	message, _ := errData["message"].(string)
 
+	// Registry auth failures need a different fix than broken packages, report them separately
+	if npmAuthErrorRe.MatchString(message) {
+		npmRegistryAuthError(line, report)
+		return
+	}
+
+	if findToolVersionMismatch(message) != nil {
+		toolVersionMismatch(line, report)
+		return
+	}
+
+	if peerConflictRe.MatchString(message) {
+		peerDependencyConflict(line, report)
+		return
+	}
+
+	if artifactResolutionRe.MatchString(message) {
+		artifactResolutionFailure(line, report)
+		return
+	}
+
+	if submoduleErrorRe.MatchString(message) {
+		submoduleUpdateFailure(line, report)
+		return
+	}
+
+	if gitLockTimeoutRe.MatchString(message) {
+		gitLockTimeout(line, report)
+		return
+	}
+
+	if branchProtectionRe.MatchString(message) {
+		branchProtectionRejection(line, report)
+		return
+	}
+
+	if networkErrorRe.MatchString(message) {
+		hostUnreachable(line, report)
+		return
+	}
+
 	if strings.Contains(message, "Failed to download metadata for repo") {
 		fields = append(fields, "Hint", "Possible Red Hat subscription activation key issue")
 	}
@@ -219,7 +611,7 @@ func rawExecError(line *LogEntry, report *SimpleReport) {
 		fields = append(fields, "Hint", fmt.Sprintf("File not found: %s, check rpms.in.yaml configuration", matches[1]))
 	}
 
-	fields = append(fields, "Message", extractUsefulErrorDefault(message))
+	fields = append(fields, "Message", extractReportError(message, report))
 
 	report.Error("Error executing command", fields...)
 }
@@ -232,15 +624,1207 @@ func platformCommitError(line *LogEntry, report *SimpleReport) {
 	}
 
 	errMessage, _ := errData["message"].(string)
+
+	// Identity, lock and branch protection problems need other fixes than a retry, report them separately
+	if gitIdentityErrorRe.MatchString(errMessage) {
+		gitIdentityError(line, report)
+		return
+	}
+
+	if gitLockTimeoutRe.MatchString(errMessage) {
+		gitLockTimeout(line, report)
+		return
+	}
+
+	if branchProtectionRe.MatchString(errMessage) {
+		branchProtectionRejection(line, report)
+		return
+	}
+
+	fields := []interface{}{"Branch", line.Extras["branch"], "Message", errMessage}
+	if fullTask := platformCommitTask(errData); fullTask != "" {
+		fields = append(fields, "Task", fullTask)
+	}
+	report.Error(line.Msg, fields...)
+}
+
+// platformCommitTask joins the git commands of the failed task, or returns an empty string
+// if the error has no task or its commands aren't a list
+func platformCommitTask(errData map[string]interface{}) string {
+	task, ok := errData["task"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	commands, ok := task["commands"].([]interface{})
+	if !ok {
+		return ""
+	}
+
 	fullTask := ""
-	for _, cmd := range errData["task"].(map[string]interface{})["commands"].([]interface{}) {
-		fullTask = fmt.Sprintf("%s %s", fullTask, cmd)
+	for _, cmd := range commands {
+		if cmd, ok := cmd.(string); ok {
+			fullTask = fmt.Sprintf("%s %s", fullTask, cmd)
+		}
+	}
+	return fullTask
+}
+
+// npmAuthErrorRe matches npm registry authentication error codes in command output
+var npmAuthErrorRe = regexp.MustCompile(`npm (?:ERR!|error) code (E401|E403)`)
+
+// registryAuthResponseRe matches the unauthorized response of a registry logged by Renovate itself
+var registryAuthResponseRe = regexp.MustCompile(`Response code (401)`)
+
+// npmRegistryURLRe matches the registry URL reported by npm in its error output
+var npmRegistryURLRe = regexp.MustCompile(`npm (?:ERR!|error) .*?https?://([^/\s'"]+)`)
+
+// npmRegistryAuthError checks for npm registry authentication failures, in command output or logged by Renovate itself
+func npmRegistryAuthError(line *LogEntry, report *SimpleReport) {
+	message := line.Msg
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		if errMessage, ok := errData["message"].(string); ok && errMessage != "" {
+			message = errMessage
+		}
+	}
+
+	matches := npmAuthErrorRe.FindStringSubmatch(message)
+	if matches == nil {
+		matches = npmAuthErrorRe.FindStringSubmatch(line.Msg)
+	}
+	if matches == nil {
+		matches = registryAuthResponseRe.FindStringSubmatch(line.Msg)
+	}
+	if matches == nil {
+		return
+	}
+
+	registry := "unknown"
+	if hostMatches := npmRegistryURLRe.FindStringSubmatch(message); hostMatches != nil {
+		registry = hostMatches[1]
+	} else {
+		for _, key := range []string{"registryUrl", "url"} {
+			value, _ := line.Extras[key].(string)
+			if hostMatches := rootCauseHostRe.FindStringSubmatch(value); hostMatches != nil {
+				registry = hostMatches[1]
+				break
+			}
+		}
+	}
+
+	fields := appendExtra(nil, line, "Branch", "branch")
+	fields = append(fields, "Code", matches[1], "Registry", registry)
+	fields = appendExtra(fields, line, "Dependency", "depName")
+	report.Error("npm registry authentication failed",
+		append(fields, "Hint", "Check the npm token configured for this registry")...,
+	)
+}
+
+// missingManifest checks for artifact updates failing because an expected manifest or lock file doesn't exist
+func missingManifest(line *LogEntry, report *SimpleReport) {
+	fields := appendExtra(nil, line, "Branch", "branch")
+	fields = appendExtra(fields, line, "File", "packageFile")
+	report.Error("Package file or lock file not found",
+		append(fields, "Hint", "Verify the file paths in the Renovate config, e.g. the fileMatch/managerFilePatterns and includePaths settings",
+			"Message", line.Msg)...,
+	)
+}
+
+// noEnabledManagers checks for a manager configuration that leaves no package manager enabled
+func noEnabledManagers(line *LogEntry, report *SimpleReport) {
+	managers, ok := line.Extras["enabledManagers"].([]interface{})
+	if !ok || len(managers) > 0 {
+		return
+	}
+
+	report.Warning("No package managers are enabled",
+		"Hint", "Check the enabledManagers and disabledManagers settings in the Renovate config",
+	)
+}
+
+// toolVersionMismatchPatterns match the tool version mismatch messages of the supported toolchains
+var toolVersionMismatchPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?P<tool>go): go\.mod requires go (?P<expected>>= [\w.\-]+) \(running go (?P<actual>[\w.\-]+)`),
+	regexp.MustCompile(`Current (?P<tool>Python) version \((?P<actual>[^)]+)\) is not allowed by the project \((?P<expected>[^)]+)\)`),
+	regexp.MustCompile(`The engine "(?P<tool>[\w\-]+)" is incompatible with this module\. Expected version "(?P<expected>[^"]+)"\. Got "(?P<actual>[^"]+)"`),
+}
+
+// findToolVersionMismatch returns the tool, expected and actual versions of the first
+// tool version mismatch found in the message, or nil if there is none
+func findToolVersionMismatch(message string) map[string]string {
+	for _, pattern := range toolVersionMismatchPatterns {
+		matches := pattern.FindStringSubmatch(message)
+		if matches == nil {
+			continue
+		}
+
+		result := make(map[string]string)
+		for i, name := range pattern.SubexpNames() {
+			if name != "" {
+				result[name] = matches[i]
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// toolVersionMismatch checks for commands failing because of a wrong tool version
+func toolVersionMismatch(line *LogEntry, report *SimpleReport) {
+	errData, ok := line.Extras["err"].(map[string]interface{})
+	if !ok {
+		return
 	}
 
-	report.Error(
-		line.Msg,
+	message, _ := errData["message"].(string)
+	mismatch := findToolVersionMismatch(message)
+	if mismatch == nil {
+		return
+	}
+
+	report.Error("Tool version mismatch",
 		"Branch", line.Extras["branch"],
-		"Message", errMessage,
-		"Task", fullTask,
+		"Tool", mismatch["tool"],
+		"Expected", mismatch["expected"],
+		"Actual", mismatch["actual"],
+		"Hint", fmt.Sprintf("Configure the %s version required by the repository", mismatch["tool"]),
+	)
+}
+
+// packageLookupFailures checks for the summary of dependencies that could not be looked up
+func packageLookupFailures(line *LogEntry, report *SimpleReport) {
+	warnings, ok := line.Extras["warnings"].([]interface{})
+	if !ok || len(warnings) == 0 {
+		return
+	}
+
+	var failures []string
+	for _, warning := range warnings {
+		failures = append(failures, fmt.Sprintf("\n%v", warning))
+	}
+
+	var fields []interface{}
+	if files, ok := line.Extras["files"].([]interface{}); ok && len(files) > 0 {
+		var fileNames []string
+		for _, file := range files {
+			fileNames = append(fileNames, fmt.Sprintf("%v", file))
+		}
+		fields = append(fields, "Files", strings.Join(fileNames, ", "))
+	}
+	fields = append(fields, "Failures", strings.Join(failures, ""))
+
+	report.Warning("Package lookup failures", fields...)
+}
+
+// graphqlError checks for failed GitHub GraphQL requests
+func graphqlError(line *LogEntry, report *SimpleReport) {
+	errData, ok := line.Extras["err"].(map[string]interface{})
+	if !ok || !isWarningOrAbove(line) {
+		return
+	}
+
+	errType, _ := errData["type"].(string)
+	if errors, ok := errData["errors"].([]interface{}); ok && errType == "" {
+		for _, graphqlErr := range errors {
+			if graphqlErr, ok := graphqlErr.(map[string]interface{}); ok {
+				if errType, ok = graphqlErr["type"].(string); ok {
+					break
+				}
+			}
+		}
+	}
+	if errType == "" {
+		errType = "unknown"
+	}
+
+	message, _ := errData["message"].(string)
+	report.Warning("GitHub GraphQL request failed",
+		"Type", errType,
+		"Message", message,
 	)
 }
+
+// lengthLimitExceeded checks for generated branch names or commit messages exceeding the platform limits
+func lengthLimitExceeded(line *LogEntry, report *SimpleReport) {
+	// Renovate logs the truncated names in the context of the branch
+	if _, ok := line.Extras["branch"].(string); !ok {
+		return
+	}
+
+	var subject string
+	switch msg := strings.ToLower(line.Msg); {
+	case strings.Contains(msg, "branch"):
+		subject = "branch name"
+	case strings.Contains(msg, "commit"):
+		subject = "commit message"
+	default:
+		return
+	}
+
+	var fields []interface{}
+	fields = appendExtra(fields, line, "Dependency", "depName")
+	fields = appendExtra(fields, line, "Branch", "branch")
+	fields = append(fields, "Hint", "Shorten the generated names by customizing the branchName/commitMessage templates (e.g. branchTopic, commitMessageTopic)")
+
+	report.Warning(fmt.Sprintf("Generated %s exceeds the length limit", subject), fields...)
+}
+
+// pathFiltersMatchNothing checks for includePaths/ignorePaths filters leaving no files to extract dependencies from
+func pathFiltersMatchNothing(line *LogEntry, report *SimpleReport) {
+	fileList, ok := line.Extras["fileList"].([]interface{})
+	if !ok || len(fileList) > 0 {
+		return
+	}
+
+	includePaths, _ := line.Extras["includePaths"].([]interface{})
+	ignorePaths, _ := line.Extras["ignorePaths"].([]interface{})
+	if len(includePaths) == 0 && len(ignorePaths) == 0 {
+		return
+	}
+
+	var fields []interface{}
+	if len(includePaths) > 0 {
+		fields = append(fields, "IncludePaths", includePaths)
+	}
+	if len(ignorePaths) > 0 {
+		fields = append(fields, "IgnorePaths", ignorePaths)
+	}
+	fields = append(fields, "Hint", "No files match the configured path filters, check the includePaths and ignorePaths globs")
+
+	report.Warning("Path filters match no files", fields...)
+}
+
+// unexpectedFileChanges checks for artifact updates changing files that were not expected to change
+func unexpectedFileChanges(line *LogEntry, report *SimpleReport) {
+	var fileNames []string
+	if files, ok := line.Extras["files"].([]interface{}); ok {
+		for _, file := range files {
+			// files are logged either as paths or as objects with a path
+			if fileMap, ok := file.(map[string]interface{}); ok {
+				file = fileMap["path"]
+			}
+			fileNames = append(fileNames, fmt.Sprintf("%v", file))
+		}
+	}
+
+	var fields []interface{}
+	fields = appendExtra(fields, line, "Branch", "branch")
+	if len(fileNames) > 0 {
+		fields = append(fields, "Files", strings.Join(fileNames, ", "))
+	}
+	fields = append(fields, "Hint", "The lock file generation may not be deterministic or the checkout may be dirty")
+
+	report.Warning("Artifact update changed unexpected files", fields...)
+}
+
+// peerConflictRe matches npm and pnpm peer dependency conflicts in command output
+var peerConflictRe = regexp.MustCompile(`\bERESOLVE\b|(?i)conflicting peer dependency|ERR_PNPM_PEER_DEP_ISSUES`)
+
+// peerConflictPackageRes extract the packages involved in a peer dependency conflict
+var peerConflictPackageRes = []*regexp.Regexp{
+	regexp.MustCompile(`Found: (\S+)`),
+	regexp.MustCompile(`peer (\S+ from \S+)`),
+	regexp.MustCompile(`(?i)conflicting peer dependency: (\S+)`),
+}
+
+// peerDependencyConflict checks for lock file updates failing on conflicting peer dependencies
+func peerDependencyConflict(line *LogEntry, report *SimpleReport) {
+	errData, ok := line.Extras["err"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	message, _ := errData["message"].(string)
+	if !peerConflictRe.MatchString(message) {
+		return
+	}
+
+	var packages []string
+	for _, pattern := range peerConflictPackageRes {
+		for _, matches := range pattern.FindAllStringSubmatch(message, -1) {
+			if !slices.Contains(packages, matches[1]) {
+				packages = append(packages, matches[1])
+			}
+		}
+	}
+
+	fields := []interface{}{"Branch", line.Extras["branch"]}
+	if len(packages) > 0 {
+		fields = append(fields, "Packages", strings.Join(packages, ", "))
+	}
+	fields = append(fields, "Hint", "Group the conflicting updates together or add overrides for the peer dependency")
+
+	report.Error("Peer dependency conflict", fields...)
+}
+
+// gitIdentityErrorRe matches git commits or pushes rejected because of the author/committer identity
+var gitIdentityErrorRe = regexp.MustCompile(`(?i)author identity unknown|please tell me who you are|` +
+	`(author|committer)( email)?\b.*\b(not allowed|not verified|does not match|is not a member)|` +
+	`can only push commits if the committer email|\bGH007\b`)
+
+// commitFilesError checks for commits rejected by the git host, e.g. because of the author identity or branch protection
+func commitFilesError(line *LogEntry, report *SimpleReport) {
+	errData, ok := line.Extras["err"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	message, _ := errData["message"].(string)
+	if branchProtectionRe.MatchString(message) {
+		branchProtectionRejection(line, report)
+		return
+	}
+	gitIdentityError(line, report)
+}
+
+// gitIdentityError checks for commits rejected because of a missing or disallowed git author identity
+func gitIdentityError(line *LogEntry, report *SimpleReport) {
+	errData, ok := line.Extras["err"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	message, _ := errData["message"].(string)
+	identityLine := ""
+	for _, messageLine := range strings.Split(message, "\n") {
+		if gitIdentityErrorRe.MatchString(messageLine) {
+			identityLine = strings.TrimSpace(messageLine)
+			break
+		}
+	}
+	if identityLine == "" {
+		return
+	}
+
+	report.Error("Git author identity rejected",
+		"Branch", line.Extras["branch"],
+		"Reason", identityLine,
+		"Hint", "Check the gitAuthor setting in the Renovate config and the allowed commit emails of the git host",
+	)
+}
+
+// httpRequestURLRe matches the URL of Renovate's HTTP request log messages, e.g. "GET https://host/path = (...)"
+var httpRequestURLRe = regexp.MustCompile(`^[A-Z]+ https?://([^/\s]+)(\S*)`)
+
+// registryThrottled checks for package registries rate limiting Renovate's requests
+func registryThrottled(line *LogEntry, report *SimpleReport) {
+	matches := httpRequestURLRe.FindStringSubmatch(line.Msg)
+	if matches == nil {
+		return
+	}
+
+	host, urlPath := matches[1], matches[2]
+	// git platform rate limits are a different problem than throttled registries
+	if host == "api.github.com" || strings.HasPrefix(urlPath, "/api/v4/") || strings.HasPrefix(urlPath, "/api/graphql") {
+		platformRateLimit(line, report)
+		return
+	}
+
+	report.Warning("Package registry is throttling requests",
+		"Registry", host,
+		"Hint", "Use a registry mirror or reduce the Renovate concurrency for this registry (hostRules concurrentRequestLimit)",
+	)
+}
+
+// secondaryRateLimitRe matches secondary rate limits, which throttle bursts of requests rather than the hourly quota
+var secondaryRateLimitRe = regexp.MustCompile(`(?i)secondary rate limit|abuse detection`)
+
+// rateLimitPlatforms maps substrings of the API URL or error message to the name of the git platform
+var rateLimitPlatforms = []struct {
+	substring string
+	name      string
+}{
+	{"github", "GitHub"},
+	{"/api/graphql", "GitHub"},
+	{"gitlab", "GitLab"},
+	{"/api/v4/", "GitLab"},
+	{"bitbucket", "Bitbucket"},
+	{"gitea", "Gitea"},
+	{"forgejo", "Forgejo"},
+}
+
+// platformRateLimit checks for the git platform API rate limiting the token Renovate uses,
+// only the first rate limit of each kind is reported as the values like the reset time differ
+func platformRateLimit(line *LogEntry, report *SimpleReport) {
+	message := line.Msg
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		if errMessage, ok := errData["message"].(string); ok {
+			message = fmt.Sprintf("%s %s", message, errMessage)
+		}
+	}
+
+	source := strings.ToLower(message)
+	if requestURL, ok := line.Extras["url"].(string); ok {
+		source = strings.ToLower(requestURL) + " " + source
+	}
+	platform := "Git platform"
+	for _, candidate := range rateLimitPlatforms {
+		if strings.Contains(source, candidate.substring) {
+			platform = candidate.name
+			break
+		}
+	}
+
+	limit := "primary"
+	if secondaryRateLimitRe.MatchString(message) {
+		limit = "secondary"
+	}
+
+	key := fmt.Sprintf("rateLimit:%s:%s", platform, limit)
+	report.count(key)
+	if report.counts[key] > 1 {
+		return
+	}
+
+	fields := []interface{}{"Platform", platform, "Limit", limit}
+	if reset := rateLimitReset(line); reset != "" {
+		fields = append(fields, "Reset", reset)
+	}
+	report.Warning(fmt.Sprintf("%s API rate limit exceeded", platform),
+		append(fields, "Hint", fmt.Sprintf("The %s token may be shared with other jobs or throttled, use a dedicated token for Renovate or reduce its concurrency", platform))...,
+	)
+}
+
+// rateLimitReset returns when the rate limit resets from the retryAfter field or the
+// Retry-After and X-RateLimit-Reset response headers of the error, if any
+func rateLimitReset(line *LogEntry) string {
+	if retryAfter, ok := line.Extras["retryAfter"]; ok && retryAfter != nil {
+		return fmt.Sprintf("retry after %vs", retryAfter)
+	}
+
+	errData, _ := line.Extras["err"].(map[string]interface{})
+	headers, ok := errData["headers"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if retryAfter, ok := headers["retry-after"].(string); ok && retryAfter != "" {
+		return fmt.Sprintf("retry after %ss", retryAfter)
+	}
+	if reset, ok := headers["x-ratelimit-reset"].(string); ok {
+		if epoch, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			return time.Unix(epoch, 0).UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+// prCreated counts the pull requests created by Renovate
+func prCreated(line *LogEntry, report *SimpleReport) {
+	report.count("prsCreated")
+}
+
+// prUpdated counts the pull requests updated by Renovate
+func prUpdated(line *LogEntry, report *SimpleReport) {
+	report.count("prsUpdated")
+}
+
+// summarizePRs reports how many pull requests Renovate created and updated
+func summarizePRs(report *SimpleReport) {
+	created, updated := report.counts["prsCreated"], report.counts["prsUpdated"]
+	if created == 0 && updated == 0 {
+		return
+	}
+	report.Info(fmt.Sprintf("Renovate created %d PRs, updated %d", created, updated))
+}
+
+// staleBranchCleanupFailure checks for Renovate failing to delete branches it no longer needs
+func staleBranchCleanupFailure(line *LogEntry, report *SimpleReport) {
+	fields := []interface{}{"Branch", line.Extras["branch"]}
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		if message, ok := errData["message"].(string); ok && message != "" {
+			reason, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+			fields = append(fields, "Reason", reason)
+		}
+	}
+	fields = append(fields, "Hint", "Check that branch protection rules allow the Renovate user to delete its branches")
+
+	report.Warning("Failed to delete stale Renovate branch", fields...)
+}
+
+// submoduleErrorRe matches git output of a failed submodule clone, checkout or update
+var submoduleErrorRe = regexp.MustCompile(`(?i)submodule path '[^']+'|no url found for submodule|` +
+	`failed to clone '[^']+'|failed to recurse into submodule`)
+
+// submodulePathRes match the submodule path in git's submodule error output, relative paths first
+var submodulePathRes = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)failed to clone '([^']+)'`),
+	regexp.MustCompile(`(?i)submodule path '([^']+)'`),
+	regexp.MustCompile(`(?i)failed to recurse into submodule '([^']+)'`),
+}
+
+// submoduleUpdateFailure checks for Renovate failing to clone or update git submodules
+func submoduleUpdateFailure(line *LogEntry, report *SimpleReport) {
+	fields := []interface{}{"Branch", line.Extras["branch"]}
+
+	message := ""
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		message, _ = errData["message"].(string)
+	}
+	for _, pathRe := range submodulePathRes {
+		if matches := pathRe.FindStringSubmatch(message); matches != nil {
+			fields = append(fields, "Submodule", matches[1])
+			break
+		}
+	}
+	fields = append(fields,
+		"Hint", "Check that the submodule URLs in .gitmodules are reachable with Renovate's credentials and the referenced commits exist",
+		"Message", extractReportError(message, report),
+	)
+
+	report.Error("Failed to update git submodule", fields...)
+}
+
+// vulnerabilityNoFixRe matches the advisory ID and the package of Renovate's vulnerability messages,
+// e.g. "No fixed version available for vulnerability GHSA-xxxx-xxxx-xxxx in lodash 4.17.20"
+var vulnerabilityNoFixRe = regexp.MustCompile(`vulnerability (\S+) in (\S+)`)
+
+// vulnerabilityFixUnavailable checks for vulnerability alerts without any fixed version to update to
+func vulnerabilityFixUnavailable(line *LogEntry, report *SimpleReport) {
+	advisory := ""
+	depName, _ := line.Extras["depName"].(string)
+	if matches := vulnerabilityNoFixRe.FindStringSubmatch(line.Msg); matches != nil {
+		advisory = matches[1]
+		if depName == "" {
+			depName = matches[2]
+		}
+	}
+
+	var fields []interface{}
+	if depName != "" {
+		fields = append(fields, "Dependency", depName)
+	}
+	if advisory != "" {
+		fields = append(fields, "Advisory", advisory)
+	}
+	fields = append(fields, "Hint", "The alert is known but cannot be fixed by an update yet, consider a workaround or replacing the dependency")
+
+	report.Warning("Vulnerability has no fixed version available", fields...)
+}
+
+// platformDeprecation checks for deprecation notices of the platform API used by Renovate
+func platformDeprecation(line *LogEntry, report *SimpleReport) {
+	if !isWarningOrAbove(line) {
+		return
+	}
+	report.Info("Platform API deprecation notice", platformDeprecationFields(line)...)
+}
+
+// platformDeprecationWarning reports platform API deprecation notices as warnings
+func platformDeprecationWarning(line *LogEntry, report *SimpleReport) {
+	if !isWarningOrAbove(line) {
+		return
+	}
+	report.Warning("Platform API deprecation notice", platformDeprecationFields(line)...)
+}
+
+// platformDeprecationFields builds the report fields of a platform API deprecation notice
+func platformDeprecationFields(line *LogEntry) []interface{} {
+	fields := []interface{}{}
+	if matches := rootCauseHostRe.FindStringSubmatch(line.Msg); matches != nil {
+		fields = append(fields, "Host", matches[1])
+	}
+	return append(fields,
+		"Hint", "Renovate or the platform will stop supporting this API, plan the migration ahead",
+		"Message", line.Msg,
+	)
+}
+
+// unmatchedPackageRules checks for packageRules that didn't match any dependency, e.g. because of a typo
+func unmatchedPackageRules(line *LogEntry, report *SimpleReport) {
+	rules, ok := line.Extras["packageRules"].([]interface{})
+	if !ok {
+		return
+	}
+
+	for _, rule := range rules {
+		ruleMap, ok := rule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Only the selectors identify a rule, its other settings may be shared by many rules
+		var matchers []string
+		for key, value := range ruleMap {
+			if strings.HasPrefix(key, "match") || strings.HasPrefix(key, "exclude") {
+				matchers = append(matchers, fmt.Sprintf("%s=%v", key, value))
+			}
+		}
+		slices.Sort(matchers)
+
+		var fields []interface{}
+		if description, ok := ruleMap["description"]; ok {
+			fields = append(fields, "Description", description)
+		}
+		fields = append(fields,
+			"Rule", strings.Join(matchers, ", "),
+			"Hint", "Check the match* values of the rule for typos, it doesn't apply to any dependency",
+		)
+
+		report.Warning("Package rule matches no dependencies", fields...)
+	}
+}
+
+// cacheWriteFailure checks for Renovate failing to write its package or repository cache
+func cacheWriteFailure(line *LogEntry, report *SimpleReport) {
+	reason := ""
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		message, _ := errData["message"].(string)
+		reason, _, _ = strings.Cut(strings.TrimSpace(message), "\n")
+		// Leave out the path of the cache entry, so the failures of all entries are reported once
+		reason, _, _ = strings.Cut(reason, ", ")
+	}
+
+	// A full disk affects more than the cache, it needs a different fix than the cache volume
+	hint := "Check that the cache directory is on a writable volume, e.g. not mounted read-only and owned by the Renovate user"
+	if strings.Contains(reason, "ENOSPC") {
+		hint = "The disk is full, increase the volume size or clean up the cache directory"
+	}
+
+	fields := []interface{}{}
+	if reason != "" {
+		fields = append(fields, "Reason", reason)
+	}
+	fields = append(fields, "Hint", hint)
+
+	report.Warning("Failed to write Renovate cache", fields...)
+}
+
+// changelogFailurePrefix prefixes the counter keys of the changelog fetch failures by source
+const changelogFailurePrefix = "changelogFailures:"
+
+// changelogFetchFailure counts the changelog fetch failures by the host of the changelog source
+func changelogFetchFailure(line *LogEntry, report *SimpleReport) {
+	source := "unknown"
+	sourceURL, _ := line.Extras["sourceUrl"].(string)
+	if matches := rootCauseHostRe.FindStringSubmatch(sourceURL); matches != nil {
+		source = matches[1]
+	}
+	report.count(changelogFailurePrefix + source)
+}
+
+// summarizeChangelogFailures reports a single warning with the changelog fetch failures of each source
+func summarizeChangelogFailures(report *SimpleReport) {
+	var sources []string
+	total := 0
+	for key, count := range report.counts {
+		if source, found := strings.CutPrefix(key, changelogFailurePrefix); found {
+			sources = append(sources, fmt.Sprintf("%s (%d)", source, count))
+			total += count
+		}
+	}
+	if total == 0 {
+		return
+	}
+	slices.Sort(sources)
+
+	report.Warning(fmt.Sprintf("Failed to fetch %d changelogs", total),
+		"Sources", strings.Join(sources, ", "),
+		"Hint", "The PRs of these updates miss release notes, check that the source repositories exist and are accessible",
+	)
+}
+
+// proposedUpdate reports each dependency update proposed by Renovate, for update telemetry
+func proposedUpdate(line *LogEntry, report *SimpleReport) {
+	depName, ok := line.Extras["depName"].(string)
+	if !ok || depName == "" {
+		return
+	}
+	newValue, ok := line.Extras["thisNewValue"]
+	if !ok {
+		newValue = line.Extras["newValue"]
+	}
+
+	fields := []interface{}{
+		"Dependency", depName,
+		"Update", fmt.Sprintf("%v -> %v", line.Extras["currentValue"], newValue),
+	}
+	fields = appendExtra(fields, line, "Manager", "manager")
+	fields = appendExtra(fields, line, "PackageFile", "packageFile")
+
+	report.Info("Dependency update proposed", fields...)
+}
+
+// gitLockTimeoutRe matches git operations that gave up waiting for a lock held by a concurrent git process
+var gitLockTimeoutRe = regexp.MustCompile(`(?i)unable to create '[^']+\.lock'|another git process seems to be running|` +
+	`timed? ?out waiting for (the )?(git )?lock`)
+
+// gitLockTimeout checks for git operations timing out on a lock held by a concurrent git operation
+func gitLockTimeout(line *LogEntry, report *SimpleReport) {
+	lockLine := ""
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		message, _ := errData["message"].(string)
+		for _, messageLine := range strings.Split(message, "\n") {
+			if gitLockTimeoutRe.MatchString(messageLine) {
+				lockLine = strings.TrimSpace(messageLine)
+				break
+			}
+		}
+	}
+
+	report.Warning("Timed out waiting for a git lock",
+		"Branch", line.Extras["branch"],
+		"Reason", lockLine,
+		"Hint", "Concurrent git operations contend for the same lock, reduce the parallelism of the Renovate jobs sharing the git backend",
+	)
+}
+
+// branchProtectionRe matches pushes and merges rejected by the branch protection rules of the git host
+var branchProtectionRe = regexp.MustCompile(`(?i)\bGH006\b|protected branch (update failed|hook declined)|` +
+	`not allowed to (push|force push|merge).*protected branch|required status checks? .*(expected|failing|not)|` +
+	`approving reviews? (is|are) required|changes must be made through a pull request`)
+
+// branchProtectionRejection checks for Renovate's pushes or automerges rejected by branch protection rules
+func branchProtectionRejection(line *LogEntry, report *SimpleReport) {
+	reason := ""
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		message, _ := errData["message"].(string)
+		for _, messageLine := range strings.Split(message, "\n") {
+			if branchProtectionRe.MatchString(messageLine) {
+				reason = strings.TrimSpace(messageLine)
+				break
+			}
+		}
+	}
+	if reason == "" {
+		return
+	}
+
+	report.Warning("Branch protection rejected Renovate",
+		"Branch", line.Extras["branch"],
+		"Reason", reason,
+		"Hint", "Check the branch protection rules of the target branch and that the Renovate user is allowed to push, or bypass the required reviews and status checks for automerge",
+	)
+}
+
+// configParseLocationRes match the line and column of JSON/JSON5 parse errors
+var configParseLocationRes = []*regexp.Regexp{
+	regexp.MustCompile(`at (\d+):(\d+)`),
+	regexp.MustCompile(`(?i)line (\d+),? column (\d+)`),
+	regexp.MustCompile(`(?i)at position (\d+)()`),
+}
+
+// configSyntaxError checks for Renovate config files that can't be parsed, unlike the schema validation errors
+func configSyntaxError(line *LogEntry, report *SimpleReport) {
+	if !isWarningOrAbove(line) {
+		return
+	}
+
+	parseError := ""
+	for _, key := range []string{"validationMessage", "errorMessage"} {
+		if message, ok := line.Extras[key].(string); ok && message != "" {
+			parseError = message
+			break
+		}
+	}
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok && parseError == "" {
+		parseError, _ = errData["message"].(string)
+	}
+	// A syntax error is logged with the parse error or the config file it is about
+	if _, ok := line.Extras["validationSource"]; !ok && parseError == "" {
+		return
+	}
+
+	var fields []interface{}
+	fields = appendExtra(fields, line, "File", "validationSource")
+	for _, locationRe := range configParseLocationRes {
+		if matches := locationRe.FindStringSubmatch(parseError); matches != nil {
+			location := "position " + matches[1]
+			if matches[2] != "" {
+				location = fmt.Sprintf("line %s, column %s", matches[1], matches[2])
+			}
+			fields = append(fields, "Location", location)
+			break
+		}
+	}
+	if parseError != "" {
+		fields = append(fields, "Reason", strings.TrimSpace(parseError))
+	}
+	fields = append(fields, "Hint", "Fix the syntax of the config file, e.g. unbalanced braces, missing commas or comments in a plain JSON file")
+
+	report.Error("Renovate config file can't be parsed", fields...)
+}
+
+// customManagerExtractionFailure checks for custom managers (regexManagers) failing to extract dependencies
+func customManagerExtractionFailure(line *LogEntry, report *SimpleReport) {
+	manager, _ := line.Extras["manager"].(string)
+	errData, ok := line.Extras["err"].(map[string]interface{})
+	if !ok || (manager != "regex" && !strings.HasPrefix(manager, "custom.")) {
+		return
+	}
+
+	fields := []interface{}{"Manager", manager}
+	fields = appendExtra(fields, line, "File", "packageFile")
+	if message, ok := errData["message"].(string); ok && message != "" {
+		reason, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		fields = append(fields, "Reason", reason)
+	}
+	fields = append(fields, "Hint", "The updates of this custom manager are skipped, check its matchStrings and templates against the file")
+
+	report.Warning("Custom manager failed to extract dependencies", fields...)
+}
+
+// cloneFailureCauses classify the underlying cause of a failed repository clone, the first match wins
+var cloneFailureCauses = []struct {
+	cause   string
+	hint    string
+	pattern *regexp.Regexp
+}{
+	{"repository not found", "Check that the repository exists and wasn't renamed or moved",
+		regexp.MustCompile(`(?i)repository not found|not found|does not appear to be a git repository|does not exist`)},
+	{"authentication", "Check the token Renovate uses and its access to the repository",
+		regexp.MustCompile(`(?i)authentication failed|could not read username|permission denied|\b(401|403)\b|access denied`)},
+	{"network", "Check the network connectivity to the git host, e.g. DNS, proxy and firewall settings",
+		regexp.MustCompile(`(?i)could not resolve host|timed out|failed to connect|connection (refused|reset)|\bE(CONNRESET|TIMEDOUT|NOTFOUND)\b|unable to access`)},
+}
+
+// cloneURLRe matches the URL of the cloned repository in git's output
+var cloneURLRe = regexp.MustCompile(`https?://[^\s'"]+`)
+
+// repositoryCloneFailure checks for Renovate failing to clone the repository, nothing else can run then
+func repositoryCloneFailure(line *LogEntry, report *SimpleReport) {
+	// Renovate retries the clone at debug level, only the final failure is a warning or above
+	if !isWarningOrAbove(line) {
+		return
+	}
+
+	message := ""
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		message, _ = errData["message"].(string)
+	}
+	// The URLs may carry the token Renovate authenticates with
+	message = redactURLCredentials(message)
+
+	var fields []interface{}
+	if cloneURL := cloneURLRe.FindString(message); cloneURL != "" {
+		fields = append(fields, "URL", cloneURL)
+	}
+
+	cause, hint := "unknown", "Check the git output below for the cause"
+	for _, cloneFailure := range cloneFailureCauses {
+		if cloneFailure.pattern.MatchString(message) {
+			cause, hint = cloneFailure.cause, cloneFailure.hint
+			break
+		}
+	}
+	fields = append(fields,
+		"Cause", cause,
+		"Hint", hint,
+		"Message", extractReportError(message, report),
+	)
+
+	report.Error("Failed to clone the repository", fields...)
+}
+
+// outsideSchedule checks for work skipped because the current time is outside the configured schedule,
+// reported once since every skipped branch logs it
+func outsideSchedule(line *LogEntry, report *SimpleReport) {
+	report.count("outsideSchedule")
+	if report.counts["outsideSchedule"] > 1 {
+		return
+	}
+
+	report.Info("Renovate skipped work outside of the configured schedule",
+		"Hint", "A successful run may not create or update PRs, check the schedule and timezone settings if updates are expected",
+	)
+}
+
+// npmPublicRegistryHost is the registry npm falls back to for scopes without a configured registry
+const npmPublicRegistryHost = "registry.npmjs.org"
+
+// npmPrivateRegistryFallthrough checks for scoped npm packages looked up on the public registry
+// instead of the private one, usually because the scope isn't mapped to the private registry
+func npmPrivateRegistryFallthrough(line *LogEntry, report *SimpleReport) {
+	depName, _ := line.Extras["depName"].(string)
+	scope, _, scoped := strings.Cut(depName, "/")
+	if !scoped || !strings.HasPrefix(scope, "@") {
+		return
+	}
+
+	registryURL, _ := line.Extras["registryUrl"].(string)
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok && registryURL == "" {
+		registryURL, _ = errData["message"].(string)
+	}
+	matches := rootCauseHostRe.FindStringSubmatch(registryURL)
+	if matches != nil && matches[1] != npmPublicRegistryHost {
+		return
+	}
+
+	report.Warning("Scoped npm package looked up on the public registry",
+		"Dependency", depName,
+		"Scope", scope,
+		"Hint", fmt.Sprintf("Map the %s scope to its private registry, e.g. with %s:registry in .npmrc or a hostRules/npmrc entry in the Renovate config", scope, scope),
+	)
+}
+
+// memoryUsageRe matches a memory amount in a log message, e.g. "3.8 GB" or "3900MiB"
+var memoryUsageRe = regexp.MustCompile(`\d+(\.\d+)?\s?[KMG]i?B\b`)
+
+// memoryPressure checks for Renovate warning about its memory usage, which precedes an OOM kill
+func memoryPressure(line *LogEntry, report *SimpleReport) {
+	if !isWarningOrAbove(line) {
+		return
+	}
+	report.Info("Renovate memory usage is high", memoryPressureFields(line)...)
+}
+
+// memoryPressureWarning reports Renovate memory pressure as a warning
+func memoryPressureWarning(line *LogEntry, report *SimpleReport) {
+	if !isWarningOrAbove(line) {
+		return
+	}
+	report.Warning("Renovate memory usage is high", memoryPressureFields(line)...)
+}
+
+// memoryPressureFields builds the report fields of a memory pressure message with the reported usage
+func memoryPressureFields(line *LogEntry) []interface{} {
+	var fields []interface{}
+	for _, usage := range []struct{ key, extra string }{{"HeapUsed", "heapUsed"}, {"HeapTotal", "heapTotal"}, {"RSS", "rss"}} {
+		if bytes, ok := line.Extras[usage.extra].(float64); ok {
+			fields = append(fields, usage.key, fmt.Sprintf("%.0f MB", bytes/(1024*1024)))
+		}
+	}
+	if len(fields) == 0 {
+		if usage := memoryUsageRe.FindString(line.Msg); usage != "" {
+			fields = append(fields, "Usage", usage)
+		}
+	}
+	return append(fields, "Hint", "The run may be killed when running out of memory, increase the memory limit of the step-renovate container")
+}
+
+// branchConflictRe matches git and Renovate output of a branch update stopped by conflicts
+var branchConflictRe = regexp.MustCompile(`(?i)\bCONFLICT \([^)]+\)|merge conflict|automatic merge failed|` +
+	`could not apply [0-9a-f]{7,}|\bbranch is conflicted\b|\bconflicts? (with|in) `)
+
+// branchConflictFileRe matches the conflicting file in git's merge output
+var branchConflictFileRe = regexp.MustCompile(`Merge conflict in (\S+)`)
+
+// branchUpdateConflict checks for Renovate failing to update a branch because of conflicts,
+// other branch update errors are left to the level based reporting
+func branchUpdateConflict(line *LogEntry, report *SimpleReport) {
+	message := line.Msg
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		if errMessage, ok := errData["message"].(string); ok {
+			message += "\n" + errMessage
+		}
+	}
+
+	reason := ""
+	var files []string
+	for _, messageLine := range strings.Split(message, "\n") {
+		if reason == "" && branchConflictRe.MatchString(messageLine) {
+			reason = strings.TrimSpace(messageLine)
+		}
+		if matches := branchConflictFileRe.FindStringSubmatch(messageLine); matches != nil {
+			files = append(files, matches[1])
+		}
+	}
+	if reason == "" {
+		return
+	}
+
+	fields := []interface{}{"Branch", line.Extras["branch"], "Reason", reason}
+	if len(files) > 0 {
+		fields = append(fields, "Files", strings.Join(files, ", "))
+	}
+	fields = append(fields, "Hint", "The base branch changed concurrently, Renovate usually rebases on the next run; otherwise rebase the branch or tick the rebase checkbox of the PR")
+
+	report.Warning("Renovate branch has conflicts", fields...)
+}
+
+// artifactResolutionRe matches Maven and Gradle output of dependencies that can't be downloaded
+var artifactResolutionRe = regexp.MustCompile(`Could not resolve dependencies for project|Could not find artifact|` +
+	`Could not transfer artifact|Failed to read artifact descriptor|Could not resolve all (files|dependencies) for configuration|` +
+	`Could not (find|resolve) [\w.\-]+:[\w.\-]+:[\w.\-]+`)
+
+// gradleOutputRe matches phrasings only Gradle uses, Maven is assumed otherwise
+var gradleOutputRe = regexp.MustCompile(`Could not resolve all (files|dependencies) for configuration|` +
+	`(?m)^\s*> Could not (find|resolve) |(?i)\bgradlew?\b`)
+
+// artifactCoordinatesRes match the coordinates of the artifacts that can't be resolved, Maven phrasings first
+var artifactCoordinatesRes = []*regexp.Regexp{
+	regexp.MustCompile(`Could not (?:find|transfer) artifact ([\w.\-]+(?::[\w.\-]+){2,4})`),
+	regexp.MustCompile(`Failed to read artifact descriptor for ([\w.\-]+(?::[\w.\-]+){2,4})`),
+	regexp.MustCompile(`Failed to collect dependencies at ([\w.\-]+(?::[\w.\-]+){2,4})`),
+	regexp.MustCompile(`artifacts could not be resolved: ([\w.\-]+(?::[\w.\-]+){2,4})`),
+	regexp.MustCompile(`Could not (?:find|resolve) ([\w.\-]+:[\w.\-]+:[\w.\-]*[\w\-])`),
+}
+
+// artifactRepositoryRe matches the host of the repository an artifact was looked up in,
+// e.g. "in central (https://repo.maven.apache.org/maven2)" or Gradle's searched locations
+var artifactRepositoryRe = regexp.MustCompile(`(?:in|from/to) [\w.\-]+ \(https?://([^/)\s]+)|Searched in the following locations:\s+- https?://([^/\s]+)`)
+
+// artifactAuthRe matches repositories rejecting the credentials while resolving artifacts
+var artifactAuthRe = regexp.MustCompile(`(?i)status code: 40[13]|\b40[13] (Unauthorized|Forbidden)\b|not authorized`)
+
+// artifactResolutionFailure checks for Maven or Gradle builds failing to download dependencies
+func artifactResolutionFailure(line *LogEntry, report *SimpleReport) {
+	message := line.Msg
+	if errData, ok := line.Extras["err"].(map[string]interface{}); ok {
+		if errMessage, ok := errData["message"].(string); ok {
+			message += "\n" + errMessage
+		}
+	}
+
+	tool := "Maven"
+	if gradleOutputRe.MatchString(message) {
+		tool = "Gradle"
+	}
+
+	var artifacts []string
+	for _, pattern := range artifactCoordinatesRes {
+		for _, matches := range pattern.FindAllStringSubmatch(message, -1) {
+			if !slices.Contains(artifacts, matches[1]) {
+				artifacts = append(artifacts, matches[1])
+			}
+		}
+	}
+	if len(artifacts) == 0 {
+		if depName, ok := line.Extras["depName"].(string); ok && depName != "" {
+			artifacts = append(artifacts, depName)
+		}
+	}
+
+	fields := []interface{}{"Branch", line.Extras["branch"]}
+	if len(artifacts) > 0 {
+		fields = append(fields, "Artifacts", strings.Join(artifacts, ", "))
+	}
+	if matches := artifactRepositoryRe.FindStringSubmatch(message); matches != nil {
+		fields = append(fields, "Repository", matches[1]+matches[2])
+	}
+	hint := "Check that the artifact exists in the configured repositories and that the repository mirrors are reachable"
+	if artifactAuthRe.MatchString(message) {
+		hint = "The repository rejected the credentials, check the hostRules credentials for this repository"
+	}
+	fields = append(fields, "Hint", hint)
+
+	report.Error(fmt.Sprintf("%s could not resolve dependencies", tool), fields...)
+}
+
+// branchResultLabels names the branch results of branchesInformation in the summary, in the order they are listed
+var branchResultLabels = []struct {
+	label   string
+	results []string
+}{
+	{"PRs created", []string{"pr-created"}},
+	{"PRs updated", []string{"pr-edited"}},
+	{"already existed", []string{"already-existed"}},
+	{"automerged", []string{"automerged"}},
+	{"done", []string{"done"}},
+	{"limited", []string{"pr-limit-reached", "commit-limit-reached", "branch-limit-reached"}},
+	{"not scheduled", []string{"not-scheduled", "update-not-scheduled"}},
+	{"pending", []string{"pending", "needs-approval", "needs-pr-approval"}},
+	{"errored", []string{"error"}},
+}
+
+// branchesSummary reports how many of the branches in branchesInformation ended in each result
+func branchesSummary(line *LogEntry, report *SimpleReport) {
+	branches, ok := line.Extras["branchesInformation"].([]interface{})
+	if !ok || len(branches) == 0 {
+		return
+	}
+
+	results := make(map[string]int)
+	for _, branch := range branches {
+		result := "unknown"
+		if branchData, ok := branch.(map[string]interface{}); ok {
+			if value, ok := branchData["result"].(string); ok && value != "" {
+				result = value
+			}
+		}
+		results[result]++
+	}
+
+	var outcomes []string
+	for _, outcome := range branchResultLabels {
+		count := 0
+		for _, result := range outcome.results {
+			count += results[result]
+			delete(results, result)
+		}
+		if count > 0 {
+			outcomes = append(outcomes, fmt.Sprintf("%d %s", count, outcome.label))
+		}
+	}
+	// results without a label, e.g. "unknown" for entries missing it, are listed by name
+	others := make([]string, 0, len(results))
+	for result := range results {
+		others = append(others, result)
+	}
+	slices.Sort(others)
+	for _, result := range others {
+		outcomes = append(outcomes, fmt.Sprintf("%d %s", results[result], result))
+	}
+
+	report.Info(fmt.Sprintf("%d branches: %s", len(branches), strings.Join(outcomes, ", ")))
+}
+
+// networkErrorRe matches DNS and connection errors, with the host or address following the error code when logged
+var networkErrorRe = regexp.MustCompile(`\b(ENOTFOUND|EAI_AGAIN|ECONNREFUSED|ENETUNREACH|EHOSTUNREACH)\b` +
+	`(?:[ \t]+(\[[0-9a-fA-F:]+\](?::\d+)?|localhost(?::\d+)?|[\w\-]+(?:\.[\w\-]+)+(?::\d+)?))?|` +
+	`Could not resolve (?:host|proxy): ([\w.\-]+)`)
+
+// networkErrorURLRe matches the URL of a request failing with a network error
+var networkErrorURLRe = regexp.MustCompile(`https?://[^\s'"<>()]+`)
+
+// hostUnreachable checks for hosts Renovate can't resolve or connect to, e.g. behind a proxy or an egress policy
+func hostUnreachable(line *LogEntry, report *SimpleReport) {
+	message := line.Msg
+	errData, _ := line.Extras["err"].(map[string]interface{})
+	// Debug and info entries only mention the error codes, unless they log the failed request
+	if errData == nil && !isWarningOrAbove(line) {
+		return
+	}
+	if errMessage, ok := errData["message"].(string); ok {
+		message += "\n" + errMessage
+	}
+
+	matches := networkErrorRe.FindStringSubmatch(message)
+	if matches == nil {
+		return
+	}
+	// git and curl name the failure instead of logging an error code
+	errorCode := matches[1]
+	if errorCode == "" {
+		errorCode, _, _ = strings.Cut(matches[0], ":")
+	}
+
+	fields := []interface{}{}
+	if host := unreachableHost(message, matches, errData, line.Extras["url"]); host != "" {
+		fields = append(fields, "Host", host)
+	}
+	fields = append(fields, "Error", errorCode)
+
+	switch errorCode {
+	case "ENOTFOUND", "EAI_AGAIN", "Could not resolve host", "Could not resolve proxy":
+		fields = append(fields, "Hint", "The host name could not be resolved, check the hostRules matchHost for typos "+
+			"and that the cluster DNS or the proxy (HTTPS_PROXY) can resolve it")
+		report.Error("Renovate could not resolve a host", fields...)
+	default:
+		fields = append(fields, "Hint", "The connection was refused or the network is unreachable, check the hostRules "+
+			"for this host and that the egress policy or the proxy (HTTPS_PROXY, NO_PROXY) allows connecting to it")
+		report.Error("Renovate could not connect to a host", fields...)
+	}
+}
+
+// unreachableHost returns the host name of a network error, without the port, taken from the bare host
+// or address following the error, which is the proxy when one is used, else from the URL of the request
+func unreachableHost(message string, matches []string, errData map[string]interface{}, requestURL interface{}) string {
+	host := matches[2] + matches[3]
+	if host == "" {
+		host, _ = errData["hostname"].(string)
+	}
+	if host != "" {
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			return hostname
+		}
+		return strings.Trim(host, "[]")
+	}
+
+	rawURL, _ := requestURL.(string)
+	if rawURL == "" {
+		rawURL = networkErrorURLRe.FindString(message)
+	}
+	if parsed, err := url.Parse(rawURL); err == nil {
+		return parsed.Hostname()
+	}
+	return ""
+}
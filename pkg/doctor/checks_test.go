@@ -0,0 +1,409 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// processLines runs the checks on the given log lines
+func processLines(t *testing.T, opts Options, lines ...string) *SimpleReport {
+	t.Helper()
+	_, report, err := ProcessLogReader(context.Background(), strings.NewReader(strings.Join(lines, "\n")+"\n"), opts)
+	if err != nil {
+		t.Fatalf("ProcessLogReader() error = %v", err)
+	}
+	return report
+}
+
+// reportEntries returns the report entries of any severity starting with the given title
+func reportEntries(report *SimpleReport, title string) []string {
+	var entries []string
+	for _, logs := range [][]string{report.Errors, report.Warnings, report.Infos} {
+		for _, log := range logs {
+			if strings.HasPrefix(log, title) {
+				entries = append(entries, log)
+			}
+		}
+	}
+	return entries
+}
+
+func TestNpmRegistryAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "command output",
+			line: `{"level":50,"msg":"rawExec err","branch":"renovate/lock-file-maintenance","err":{"message":"Command failed: npm install\nnpm ERR! code E401\nnpm ERR! 401 Unauthorized - GET https://npm.example.com/ui-kit"}}`,
+			want: []string{"npm registry authentication failed | Branch: renovate/lock-file-maintenance | Code: E401 | Registry: npm.example.com | Hint: Check the npm token configured for this registry"},
+		},
+		{
+			name: "logged by renovate",
+			line: `{"level":50,"msg":"npm ERR! code E401 Unable to authenticate","depName":"@example-org/private-lib","registryUrl":"https://npm.example.com/artifactory/api/npm/npm-virtual/"}`,
+			want: []string{"npm registry authentication failed | Code: E401 | Registry: npm.example.com | Dependency: @example-org/private-lib | Hint: Check the npm token configured for this registry"},
+		},
+		{
+			name: "unauthorized response",
+			line: `{"level":40,"msg":"Response code 401 (Unauthorized)","url":"https://npm.example.com/lodash"}`,
+			want: []string{"npm registry authentication failed | Code: 401 | Registry: npm.example.com | Hint: Check the npm token configured for this registry"},
+		},
+		{
+			name: "unknown registry",
+			line: `{"level":40,"msg":"Response code 401 (Unauthorized)"}`,
+			want: []string{"npm registry authentication failed | Code: 401 | Registry: unknown | Hint: Check the npm token configured for this registry"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := processLines(t, Options{}, tt.line)
+			got := reportEntries(report, "npm registry authentication failed")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("report entries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNpmRegistryAuthErrorFixture(t *testing.T) {
+	_, report, err := ProcessLogFile(context.Background(), "testdata/test_logs.json", Options{})
+	if err != nil {
+		t.Fatalf("ProcessLogFile() error = %v", err)
+	}
+	// The fixture logs one auth failure in command output and one logged by Renovate, each reported once
+	got := reportEntries(report, "npm registry authentication failed")
+	want := []string{
+		"npm registry authentication failed | Branch: example-org/example-repo/main/lock-file-maintenance | Code: E401 | Registry: npm.example.com | Hint: Check the npm token configured for this registry",
+		"npm registry authentication failed | Code: E401 | Registry: npm.example.com | Dependency: @example-org/private-lib | Hint: Check the npm token configured for this registry",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("report entries = %q, want %q", got, want)
+	}
+}
+
+func TestWithSeverity(t *testing.T) {
+	warning := func(line *LogEntry, report *SimpleReport) {
+		report.Warning("PR limit reached - skipping PR creation")
+	}
+	tests := []struct {
+		name     string
+		severity string
+		want     [3]int // errors, warnings, infos
+		wantErr  bool
+	}{
+		{name: "warning to error", severity: "error", want: [3]int{1, 0, 0}},
+		{name: "warning to info", severity: "info", want: [3]int{0, 0, 1}},
+		{name: "same severity", severity: "warning", want: [3]int{0, 1, 0}},
+		{name: "invalid severity", severity: "fatal", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFunc, err := WithSeverity(warning, tt.severity)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithSeverity() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			report := &SimpleReport{}
+			// The second entry is a duplicate of the moved one
+			checkFunc(&LogEntry{}, report)
+			checkFunc(&LogEntry{}, report)
+			got := [3]int{len(report.Errors), len(report.Warnings), len(report.Infos)}
+			if got != tt.want {
+				t.Errorf("report entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlatformCommitTask(t *testing.T) {
+	tests := []struct {
+		name string
+		err  string
+		want string
+	}{
+		{name: "missing task", err: `{"message":"fatal: the remote end hung up unexpectedly"}`, want: ""},
+		{name: "task not an object", err: `{"task":"push"}`, want: ""},
+		{name: "task without commands", err: `{"task":{"concurrency":1}}`, want: ""},
+		{name: "commands not a list", err: `{"task":{"commands":"push --force"}}`, want: ""},
+		{name: "non-string commands", err: `{"task":{"commands":[1,{"cmd":"push"},null]}}`, want: ""},
+		{name: "mixed commands", err: `{"task":{"commands":["push",42,"--force"]}}`, want: " push --force"},
+		{name: "string commands", err: `{"task":{"commands":["push","--force","origin"]}}`, want: " push --force origin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errData map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.err), &errData); err != nil {
+				t.Fatal(err)
+			}
+			if got := platformCommitTask(errData); got != tt.want {
+				t.Errorf("platformCommitTask() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectorMatchModes(t *testing.T) {
+	reported := func(line *LogEntry, report *SimpleReport) {
+		report.Warning("Internal registry quota reached")
+	}
+	tests := []struct {
+		name     string
+		selector string
+		mode     MatchMode
+		msg      string
+		want     bool
+	}{
+		{name: "contains matches substring", selector: "quota reached", mode: MatchContains, msg: "Internal registry quota reached for team-a", want: true},
+		{name: "exact matches whole message", selector: "Registry quota reached", mode: MatchExact, msg: "Registry quota reached", want: true},
+		// The longer message is wrongly selected as a substring, not in exact mode
+		{name: "contains selects longer message", selector: "Registry quota reached", mode: MatchContains, msg: "Registry quota reached warning was cleared", want: true},
+		{name: "exact rejects substring", selector: "Registry quota reached", mode: MatchExact, msg: "Registry quota reached warning was cleared"},
+		{name: "exact rejects case", selector: "Registry quota reached", mode: MatchExact, msg: "registry quota reached"},
+		{name: "prefix matches start", selector: "Registry quota", mode: MatchPrefix, msg: "Registry quota reached", want: true},
+		{name: "prefix rejects middle", selector: "Registry quota", mode: MatchPrefix, msg: "Internal Registry quota reached"},
+		{name: "regex matches", selector: `^Registry quota of \w+ reached$`, mode: MatchRegex, msg: "Registry quota of teamA reached", want: true},
+		{name: "regex rejects", selector: `^Registry quota of \w+ reached$`, mode: MatchRegex, msg: "Registry quota of team A reached"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{
+				Selectors:     map[string]CheckFunc{tt.selector: reported},
+				SelectorModes: map[string]MatchMode{tt.selector: tt.mode},
+			}
+			line, err := json.Marshal(map[string]interface{}{"level": 30, "msg": tt.msg})
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := processLines(t, opts, string(line))
+			if got := len(report.Warnings) == 1; got != tt.want {
+				t.Errorf("selector %q matched %q = %v, want %v", tt.selector, tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterSelectorMatch(t *testing.T) {
+	if err := RegisterSelectorMatch("(", MatchRegex, prLimitReached); err == nil {
+		t.Error("RegisterSelectorMatch() error = nil for an invalid regex")
+	}
+	if _, found := RegisteredSelectors()["("]; found {
+		t.Error("invalid regex selector was registered")
+	}
+
+	const selector = "Test exact selector"
+	if err := RegisterSelectorMatch(selector, MatchExact, prLimitReached); err != nil {
+		t.Fatalf("RegisterSelectorMatch() error = %v", err)
+	}
+	t.Cleanup(func() {
+		selectorsMu.Lock()
+		defer selectorsMu.Unlock()
+		delete(Selectors, selector)
+		delete(selectorModes, selector)
+	})
+	// The registered mode applies to the log processing, the longer message isn't selected
+	report := processLines(t, Options{}, `{"level":30,"msg":"Test exact selector"}`, `{"level":30,"msg":"Test exact selector with more text"}`)
+	if len(report.Warnings) != 1 {
+		t.Errorf("report warnings = %q, want the exact match only", report.Warnings)
+	}
+}
+
+func TestArtifactResolutionFailureFixture(t *testing.T) {
+	_, report, err := ProcessLogFile(context.Background(), "testdata/test_logs.json", Options{})
+	if err != nil {
+		t.Fatalf("ProcessLogFile() error = %v", err)
+	}
+	hint := "Hint: Check that the artifact exists in the configured repositories and that the repository mirrors are reachable"
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{
+			title: "Maven could not resolve dependencies",
+			want:  "Maven could not resolve dependencies | Branch: renovate/main-spring-boot-3.x | Artifacts: com.example.internal:shared-lib:jar:2.4.1 | Repository: repo.maven.apache.org | " + hint,
+		},
+		{
+			title: "Gradle could not resolve dependencies",
+			want:  "Gradle could not resolve dependencies | Branch: renovate/main-guava-33.x | Artifacts: com.example.internal:shared-lib:2.4.1 | Repository: nexus.example.com | " + hint,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got := reportEntries(report, tt.title)
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("report entries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectorGuards(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		title string
+		want  bool
+	}{
+		{
+			name:  "GraphQL warning",
+			line:  `{"level":40,"msg":"GraphQL response contains errors","err":{"message":"GraphQL response contains errors","type":"RATE_LIMITED"}}`,
+			title: "GitHub GraphQL request failed",
+			want:  true,
+		},
+		{
+			name:  "GraphQL debug retry",
+			line:  `{"level":20,"msg":"GraphQL query failed, retrying","err":{"message":"timeout"}}`,
+			title: "GitHub GraphQL request failed",
+		},
+		{
+			name:  "GraphQL info without err",
+			line:  `{"level":30,"msg":"Using GraphQL for branch status"}`,
+			title: "GitHub GraphQL request failed",
+		},
+		{
+			name:  "branch name too long",
+			line:  `{"level":30,"msg":"Branch name is too long, truncating","branch":"renovate/long-name"}`,
+			title: "Generated branch name exceeds the length limit",
+			want:  true,
+		},
+		{
+			name:  "too long without branch",
+			line:  `{"level":20,"msg":"Commit message body is too long for the preview, shortening"}`,
+			title: "Generated commit message exceeds the length limit",
+		},
+		{
+			name:  "enabledManagers mentioned",
+			line:  `{"level":20,"msg":"Checking enabledManagers"}`,
+			title: "No package managers are enabled",
+		},
+		{
+			name:  "statusCode=429 request",
+			line:  `{"level":20,"msg":"GET https://registry.npmjs.org/lodash = (code=ERR_NON_2XX_3XX_RESPONSE, statusCode=429 retryCount=2, duration=311)"}`,
+			title: "Package registry is throttling requests",
+			want:  true,
+		},
+		{
+			name:  "statusCode=429 mentioned",
+			line:  `{"level":30,"msg":"Retrying requests answered with statusCode=429 after a delay"}`,
+			title: "Package registry is throttling requests",
+		},
+		{
+			name:  "Error extracting without err",
+			line:  `{"level":20,"msg":"Error extracting dependencies is retried","manager":"custom.regex"}`,
+			title: "Custom manager failed to extract dependencies",
+		},
+		{
+			name:  "heap usage warning",
+			line:  `{"level":40,"msg":"High heap usage: 3.6 GB"}`,
+			title: "Renovate memory usage is high",
+			want:  true,
+		},
+		{
+			name:  "heap usage debug",
+			line:  `{"level":20,"msg":"Current heap usage 512 MB"}`,
+			title: "Renovate memory usage is high",
+		},
+		{
+			name:  "deprecated API debug",
+			line:  `{"level":20,"msg":"Skipping the deprecated API check"}`,
+			title: "Platform API deprecation notice",
+		},
+		{
+			name:  "config syntax error",
+			line:  `{"level":40,"msg":"Invalid JSON5 (parsing failed)","validationSource":"renovate.json5","validationMessage":"JSON5.parse error: invalid character ',' at 4:3"}`,
+			title: "Renovate config file can't be parsed",
+			want:  true,
+		},
+		{
+			name:  "parsing failed debug",
+			line:  `{"level":20,"msg":"Preset file (parsing failed), trying the next one","validationSource":"default.json"}`,
+			title: "Renovate config file can't be parsed",
+		},
+		{
+			name:  "parsing failed without file or error",
+			line:  `{"level":40,"msg":"Dependency version (parsing failed)"}`,
+			title: "Renovate config file can't be parsed",
+		},
+		{
+			name:  "clone failure",
+			line:  `{"level":40,"msg":"git clone error","err":{"message":"fatal: repository 'https://github.com/example-org/missing.git/' not found"}}`,
+			title: "Failed to clone the repository",
+			want:  true,
+		},
+		{
+			name:  "clone error debug retry",
+			line:  `{"level":20,"msg":"git clone error, retrying","err":{"message":"fatal: unable to access 'https://github.com/example-org/example-repo.git/': Could not resolve host: github.com"}}`,
+			title: "Failed to clone the repository",
+		},
+		{
+			name:  "ENOTFOUND request",
+			line:  `{"level":20,"msg":"GET https://nexus.example.com/maven-metadata.xml = (code=ENOTFOUND)","err":{"message":"getaddrinfo ENOTFOUND nexus.example.com"}}`,
+			title: "Renovate could not resolve a host",
+			want:  true,
+		},
+		{
+			name:  "ENOTFOUND mentioned",
+			line:  `{"level":20,"msg":"Host rules retry ENOTFOUND nexus.example.com errors"}`,
+			title: "Renovate could not resolve a host",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := processLines(t, Options{}, tt.line)
+			if got := len(reportEntries(report, tt.title)) == 1; got != tt.want {
+				t.Errorf("reported %q = %v, want %v, report %+v", tt.title, got, tt.want, report)
+			}
+		})
+	}
+}
+
+func TestVulnerabilityFixUnavailable(t *testing.T) {
+	hint := "Hint: The alert is known but cannot be fixed by an update yet, consider a workaround or replacing the dependency"
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "from the message",
+			line: `{"level":20,"msg":"No fixed version available for vulnerability GHSA-35jh-r3h4-6jhm in lodash 4.17.20"}`,
+			want: "Vulnerability has no fixed version available | Dependency: lodash | Advisory: GHSA-35jh-r3h4-6jhm | " + hint,
+		},
+		{
+			name: "logged dependency",
+			line: `{"level":20,"msg":"No fixed version available for vulnerability GHSA-35jh-r3h4-6jhm in lodash 4.17.20","depName":"@example-org/lodash"}`,
+			want: "Vulnerability has no fixed version available | Dependency: @example-org/lodash | Advisory: GHSA-35jh-r3h4-6jhm | " + hint,
+		},
+		{
+			name: "without dependency or advisory",
+			line: `{"level":20,"msg":"No fixed version available for vulnerability"}`,
+			want: "Vulnerability has no fixed version available | " + hint,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reportEntries(processLines(t, Options{}, tt.line), "Vulnerability has no fixed version available")
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("report entries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
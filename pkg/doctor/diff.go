@@ -0,0 +1,93 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+// BaselineReport is the serialized form of a report used as the baseline of a later run,
+// keeping the selector of each message to match the entries by
+type BaselineReport struct {
+	Errors    []string          `json:"errors"`
+	Warnings  []string          `json:"warnings"`
+	Infos     []string          `json:"infos"`
+	Selectors map[string]string `json:"selectors,omitempty"`
+}
+
+// ReportDiff holds the report entries that are new since a baseline report and the ones that were resolved
+type ReportDiff struct {
+	New      *SimpleReport
+	Resolved *SimpleReport
+}
+
+// NewBaselineReport returns the baseline form of the report
+func NewBaselineReport(r *SimpleReport) *BaselineReport {
+	return &BaselineReport{
+		Errors:    r.Errors,
+		Warnings:  r.Warnings,
+		Infos:     r.Infos,
+		Selectors: r.selectors,
+	}
+}
+
+// Report returns the report of the baseline, with the selectors of its messages
+func (b *BaselineReport) Report() *SimpleReport {
+	return &SimpleReport{
+		Errors:    b.Errors,
+		Warnings:  b.Warnings,
+		Infos:     b.Infos,
+		selectors: b.Selectors,
+	}
+}
+
+// DiffReports compares the current report with a baseline report, entries are matched by
+// severity, selector and message without volatile values like durations and line numbers
+func DiffReports(baseline, current *SimpleReport) ReportDiff {
+	return ReportDiff{
+		New:      missingEntries(current, baseline),
+		Resolved: missingEntries(baseline, current),
+	}
+}
+
+// missingEntries returns the entries of the report that the other report doesn't have
+func missingEntries(report, other *SimpleReport) *SimpleReport {
+	missing := &SimpleReport{}
+	entries := []struct {
+		severity string
+		logs     []string
+		other    []string
+		missing  *[]string
+	}{
+		{"error", report.Errors, other.Errors, &missing.Errors},
+		{"warning", report.Warnings, other.Warnings, &missing.Warnings},
+		{"info", report.Infos, other.Infos, &missing.Infos},
+	}
+
+	for _, entry := range entries {
+		otherKeys := make(map[string]bool)
+		for _, formatted := range entry.other {
+			otherKeys[other.fingerprintKey(entry.severity, formatted)] = true
+		}
+		for _, formatted := range entry.logs {
+			if otherKeys[report.fingerprintKey(entry.severity, formatted)] {
+				continue
+			}
+			*entry.missing = append(*entry.missing, formatted)
+			if selector := report.Selector(formatted); selector != "" {
+				missing.selector = selector
+				missing.trackSelector(formatted)
+			}
+		}
+	}
+	missing.selector = ""
+	return missing
+}
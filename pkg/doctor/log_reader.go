@@ -16,11 +16,22 @@ package doctor
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"os"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Renovate's numerical levels to standard string names
@@ -33,85 +44,584 @@ var renovateLogLevels = map[int]string{
 	60: "FATAL",
 }
 
+// crashPatterns match shell output of a step-renovate process that crashed before logging any JSON
+var crashPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)segmentation fault`),
+	regexp.MustCompile(`(?i)core dumped`),
+	regexp.MustCompile(`(?i)\bkilled\b`),
+	regexp.MustCompile(`(?i)\bAborted\b`),
+	regexp.MustCompile(`(?i)JavaScript heap out of memory`),
+	regexp.MustCompile(`(?i)out of memory`),
+	regexp.MustCompile(`(?i)command not found`),
+	regexp.MustCompile(`(?i)^\s*(/bin/)?(ba)?sh: `),
+	regexp.MustCompile(`: line \d+: `),
+}
+
+// maxSnippetBytes is the longest text of a log line logged when it fails to parse
+const maxSnippetBytes = 200
+
+// ErrNoLogOutput is returned with the report when the logs are empty or have no parseable line
+var ErrNoLogOutput = errors.New("Renovate produced no log output")
+
+// defaultMaxLineBytes is the longest log line processed unless configured otherwise
+const defaultMaxLineBytes = 1 * 1024 * 1024
+
+// defaultTailInterval is how long to wait for more logs at the end of a tailed log unless configured otherwise
+const defaultTailInterval = time.Second
+
+// requiredLogFields are the fields every Renovate log line is expected to have
+var requiredLogFields = []string{"level", "msg", "time"}
+
 // ProcessLogFile processes logs from a file instead of streaming
-func ProcessLogFile(ctx context.Context, logFilePath string) (string, *SimpleReport, error) {
+func ProcessLogFile(ctx context.Context, logFilePath string, opts Options) (string, *SimpleReport, error) {
+	failReason, report, _, err := ProcessLogFiles(ctx, []string{logFilePath}, opts)
+	return failReason, report, err
+}
+
+// ProcessLogFiles processes the logs of several files in order as a single log, e.g. of matrix Renovate
+// jobs, lines are numbered across the files. Missing files are skipped and returned as long as one is found.
+// Once the context is cancelled, the next file isn't started and the findings of the lines processed so far
+// are returned, or the cancellation error if there are none
+func ProcessLogFiles(ctx context.Context, logFilePaths []string, opts Options) (string, *SimpleReport, []string, error) {
+	var missing []string
+	var files []*logFile
+	var inputs []logInput
+	for _, logFilePath := range logFilePaths {
+		// Check if file exists, step-renovate may still be flushing it
+		if !waitForFile(ctx, logFilePath, opts.FileWaitAttempts, opts.FileWaitInterval) {
+			missing = append(missing, logFilePath)
+			continue
+		}
+
+		// Open and read the file, resuming from the given offset
+		file, offset, err := openLogFile(logFilePath, opts.StartOffset)
+		if err != nil {
+			return "", &SimpleReport{}, missing, err
+		}
+		defer file.Close()
+		files = append(files, file)
+		// Compressed logs are complete once written, only plain files are followed
+		inputs = append(inputs, logInput{reader: file, offset: offset, tail: opts.Tail && file.gzip == nil})
+	}
+	if len(inputs) == 0 {
+		return "", &SimpleReport{}, missing, fmt.Errorf("log file not found (step-renovate may not have run), path: %s", strings.Join(missing, ", "))
+	}
+
+	failReason, report, err := processLogInputs(ctx, inputs, opts)
+	// A partially decompressed log can't be trusted
+	for _, file := range files {
+		if file.gzip != nil && file.readErr != nil {
+			return "", report, missing, fmt.Errorf("corrupt gzip log file %s: %w", file.file.Name(), file.readErr)
+		}
+	}
+	return failReason, report, missing, err
+}
+
+// ProcessLogReader processes the log lines read from the reader, e.g. stdin.
+// The reader is expected to be at opts.StartOffset, which the offset reached is counted from
+func ProcessLogReader(ctx context.Context, reader io.Reader, opts Options) (string, *SimpleReport, error) {
+	return processLogInputs(ctx, []logInput{{reader: reader, offset: opts.StartOffset}}, opts)
+}
+
+// logInput is a source of log lines positioned at the given offset
+type logInput struct {
+	reader io.Reader
+	offset int64
+	tail   bool // keep reading past the end of the input
+}
+
+// processLogInputs processes the log lines of the inputs in order into a single report
+func processLogInputs(ctx context.Context, inputs []logInput, opts Options) (string, *SimpleReport, error) {
 	errorsMap := make(map[string]int)
 	fatalMap := make(map[string]int)
+	repoErrorsMap := make(map[string]map[string]int)
+	repoFatalMap := make(map[string]map[string]int)
 	report := &SimpleReport{}
+	report.maxErrorLines = opts.MaxErrorLines
 
-	// Check if file exists
-	if _, err := os.Stat(logFilePath); os.IsNotExist(err) {
-		return "", report, fmt.Errorf("log file not found (step-renovate may not have run), path: %s", logFilePath)
+	maxLineBytes := opts.MaxLineBytes
+	if maxLineBytes <= 0 {
+		maxLineBytes = defaultMaxLineBytes
 	}
 
-	// Open and read the file
-	file, err := os.Open(logFilePath)
+	// Add the enabled opt-in checks to the custom or the registered selectors
+	selectors := maps.Clone(opts.Selectors)
+	if selectors == nil {
+		selectors = RegisteredSelectors()
+	}
+	var summaries []func(report *SimpleReport)
+	for _, name := range opts.OptionalChecks {
+		check, found := optionalChecks[name]
+		if !found {
+			return "", report, fmt.Errorf("unknown optional check: %s", name)
+		}
+		maps.Copy(selectors, check.selectors)
+		if check.summary != nil {
+			summaries = append(summaries, check.summary)
+		}
+	}
+	matchers, err := selectorMatchers(selectors, opts.SelectorModes)
 	if err != nil {
-		return "", report, fmt.Errorf("failed to open log file: %w", err)
+		return "", report, err
+	}
+	summarize := func() {
+		for _, summary := range summaries {
+			summary(report)
+		}
+		report.RepositoryFailures = buildRepositoryFailures(repoErrorsMap, repoFatalMap)
+		report.failures = failureKeys(errorsMap, fatalMap)
+		report.Stats.OutsideSchedule = report.counts["outsideSchedule"]
 	}
-	defer file.Close()
 
-	// Read line by line
-	const maxBufferSize = 1 * 1024 * 1024
-	scanner := bufio.NewScanner(file)
-	buf := make([]byte, maxBufferSize)
-	scanner.Buffer(buf, maxBufferSize)
+	for _, pattern := range opts.NoisyDeps {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return "", report, fmt.Errorf("invalid noisy dependency pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, pattern := range opts.WarningPatterns {
+		normalizer, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", report, fmt.Errorf("invalid warning pattern %q: %w", pattern, err)
+		}
+		report.normalizers = append(report.normalizers, normalizer)
+	}
+
+	report.Stats.EndOffset = opts.StartOffset
+
+	tailInterval := opts.TailInterval
+	if tailInterval <= 0 {
+		tailInterval = defaultTailInterval
+	}
+	// Tailed logs are read at most for the tail timeout, across all the inputs
+	var tailDeadline time.Time
+	if opts.Tail && opts.TailTimeout > 0 {
+		tailDeadline = time.Now().Add(opts.TailTimeout)
+	}
+
+	// The line buffer also holds the line break of a line of maxLineBytes, the inputs are read one after another
+	buf := make([]byte, maxLineBytes+1)
 
 	lineCount := 0
+	parsedLines := 0
+	crashLine := ""
+	completed := false // the completion message of a tailed log was seen
 
-	for scanner.Scan() {
-		// Check cancellation every 100 lines to reduce overhead
-		if lineCount%100 == 0 {
-			select {
-			case <-ctx.Done():
-				if len(errorsMap) == 0 && len(fatalMap) == 0 && len(report.Errors) == 0 && len(report.Warnings) == 0 && len(report.Infos) == 0 {
-					return "", report, fmt.Errorf("log processing cancelled: %w", ctx.Err())
+	// processLine checks the log line numbered lineCount
+	processLine := func(line string) {
+		// Attempt to parse the JSON log line
+		entry, violations, err := parseLogLine(line, opts.ValidateSchema)
+		if err != nil {
+			if strings.TrimSpace(line) != "" {
+				report.Stats.ParseErrors++
+				if opts.Logger != nil && opts.Logger.Enabled(ctx, slog.LevelDebug) {
+					opts.Logger.Debug("Failed to parse log line", "line", lineCount, "err", err, "text", lineSnippet(line))
 				}
-				return buildErrorMessageFromLogs(errorsMap, fatalMap), report, nil
-			default:
 			}
+			// Look for crash output only as long as no JSON was logged
+			if parsedLines == 0 && crashLine == "" && isCrashLine(line) {
+				crashLine = strings.TrimSpace(line)
+			}
+			return
+		}
+		parsedLines++
+		if len(violations) > 0 {
+			report.Stats.SchemaViolations++
+		}
+		if opts.Tail && opts.TailUntil != "" && strings.Contains(entry.Msg, opts.TailUntil) {
+			completed = true
 		}
-		lineCount++
-		line := scanner.Text()
 
-		// Attempt to parse the JSON log line
-		entry, err := parseLogLine(line)
-		if err != nil {
-			continue
+		// Run-level entries without a branch are kept when filtering by branch
+		if branch, ok := entry.Extras["branch"].(string); ok && opts.Branch != "" && branch != opts.Branch {
+			return
 		}
 
-		switch entry.Level {
-		case "FATAL":
+		// Known noisy dependencies don't fail the run, their errors are reported as infos
+		report.downgrade = isNoisyDependency(&entry, opts.NoisyDeps)
+
+		repository, _ := entry.Extras["repository"].(string)
+		switch {
+		case entry.Level == "FATAL":
 			formattedErr := buildErrorMessage(entry)
 			fatalMap[formattedErr]++
-		case "ERROR":
+			countRepositoryMessage(repoFatalMap, repository, formattedErr)
+		case entry.Level == "ERROR" && report.downgrade:
+			report.Stats.Downgraded++
+		case entry.Level == "ERROR":
 			formattedErr := buildErrorMessage(entry)
 			errorsMap[formattedErr]++
+			countRepositoryMessage(repoErrorsMap, repository, formattedErr)
+		}
+
+		if opts.LineNumbers {
+			report.lineNumber = lineCount
 		}
 
 		// Check against registered selectors
-		for selector, checkFunc := range Selectors {
-			if strings.Contains(entry.Msg, selector) {
+		for selector, checkFunc := range selectors {
+			if matchers[selector](entry.Msg) {
+				report.selector = selector
 				checkFunc(&entry, report)
 			}
 		}
+		report.selector = ""
+		report.downgrade = false
+		report.lineNumber = 0
 	}
 
-	if err := scanner.Err(); err != nil {
+	// partialResult returns what was found before the logs couldn't be read further, or the error if nothing was
+	partialResult := func(err error) (string, *SimpleReport, error) {
 		if len(errorsMap) == 0 && len(fatalMap) == 0 && len(report.Errors) == 0 && len(report.Warnings) == 0 && len(report.Infos) == 0 {
-			return "", report, fmt.Errorf("error reading log file: %w", err)
+			return "", report, err
 		}
 		return buildErrorMessageFromLogs(errorsMap, fatalMap), report, nil
 	}
 
+	// readLogs checks the log lines of the reader positioned at the offset, returning the offset reached
+	readLogs := func(reader io.Reader, offset int64, tail bool) (int64, error) {
+		if tail {
+			reader = &tailReader{
+				ctx:       ctx,
+				reader:    reader,
+				interval:  tailInterval,
+				deadline:  tailDeadline,
+				completed: func() bool { return completed },
+			}
+		}
+		buffered := bufio.NewReader(reader)
+
+		// Logs re-serialized as a single JSON array are decoded element by element, the elements are numbered as lines
+		if isJSONArray(buffered) {
+			decoder := json.NewDecoder(buffered)
+			_, err := decoder.Token()
+			for err == nil && decoder.More() {
+				// Check cancellation every 100 elements to reduce overhead, the array is read again from the start
+				if lineCount%100 == 0 && ctx.Err() != nil {
+					return offset, fmt.Errorf("log processing cancelled: %w", ctx.Err())
+				}
+
+				var element json.RawMessage
+				if err = decoder.Decode(&element); err == nil {
+					lineCount++
+					processLine(string(element))
+				}
+			}
+			if err == nil {
+				_, err = decoder.Token()
+			}
+			if err != nil && ctx.Err() != nil {
+				return offset, fmt.Errorf("log processing cancelled: %w", ctx.Err())
+			}
+			if err != nil {
+				return offset + decoder.InputOffset(), fmt.Errorf("invalid JSON array log: %w", err)
+			}
+			return offset + decoder.InputOffset(), nil
+		}
+
+		// Read line by line
+		scanner := bufio.NewScanner(buffered)
+		scanner.Buffer(buf, maxLineBytes+1)
+
+		// Track the offset of the lines read, leaving out a trailing line that may still be written.
+		// Lines too long for the buffer are skipped up to their line break and yield an empty line
+		lastAdvance := 0
+		skippedBytes := 0
+		skipped := false
+		remainder := false // the partial line at the end of a tailed log
+		scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			lastAdvance = 0
+			if skippedBytes > 0 {
+				lineEnd := bytes.IndexByte(data, '\n')
+				if lineEnd < 0 && !atEOF {
+					skippedBytes += len(data)
+					return len(data), nil, nil
+				}
+				if lineEnd >= 0 {
+					lastAdvance = skippedBytes + lineEnd + 1
+					offset += int64(lastAdvance)
+					data = data[:lineEnd+1]
+				}
+				skippedBytes = 0
+				skipped = true
+				return len(data), []byte{}, nil
+			}
+
+			// A tailed log may end in the middle of a line being written, it is still checked once tailing
+			// ends but left out of the offset reached, so it is read again on the next run
+			if tail && atEOF && len(data) > 0 && bytes.IndexByte(data, '\n') < 0 {
+				remainder = true
+				return len(data), data, nil
+			}
+
+			advance, token, err := bufio.ScanLines(data, atEOF)
+			if advance == 0 && token == nil && err == nil && len(data) > maxLineBytes {
+				// Skip the line instead of stopping the scan with bufio.ErrTooLong
+				skippedBytes = len(data)
+				return len(data), nil, nil
+			}
+			if advance > 0 && data[advance-1] == '\n' {
+				lastAdvance = advance
+				offset += int64(advance)
+			}
+			return advance, token, err
+		})
+
+		for scanner.Scan() {
+			// Check cancellation every 100 lines to reduce overhead, the remainder is read after the cancellation
+			if lineCount%100 == 0 && !remainder {
+				select {
+				case <-ctx.Done():
+					// The current line is not processed anymore
+					return offset - int64(lastAdvance), fmt.Errorf("log processing cancelled: %w", ctx.Err())
+				default:
+				}
+			}
+			lineCount++
+			if skipped {
+				// The analyzer couldn't check the line, which is no finding of the analyzed run
+				skipped = false
+				report.Stats.SkippedLines++
+				if opts.Logger != nil {
+					opts.Logger.Warn("Skipped a log line exceeding the maximum line size", "line", lineCount, "limit", maxLineBytes)
+				}
+				continue
+			}
+			processLine(scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			if ctx.Err() != nil {
+				return offset, fmt.Errorf("log processing cancelled: %w", ctx.Err())
+			}
+			return offset, fmt.Errorf("error reading log file: %w", err)
+		}
+		return offset, nil
+	}
+
+	for _, input := range inputs {
+		// The next input isn't started once cancelled, even between the periodic checks
+		if ctx.Err() != nil {
+			report.Stats.LinesProcessed = lineCount
+			summarize()
+			return partialResult(fmt.Errorf("log processing cancelled: %w", ctx.Err()))
+		}
+
+		endOffset, err := readLogs(input.reader, input.offset, input.tail)
+		report.Stats.EndOffset = endOffset
+		if err != nil {
+			report.Stats.LinesProcessed = lineCount
+			summarize()
+			return partialResult(err)
+		}
+	}
+
+	report.Stats.LinesProcessed = lineCount
+	summarize()
+
+	// The file has content but step-renovate crashed before logging anything
+	if parsedLines == 0 && crashLine != "" {
+		report.Error("step-renovate crashed before logging", "Output", crashLine)
+		return fmt.Sprintf("step-renovate crashed before logging: %s", crashLine), report, nil
+	}
+
+	// An empty log or one without any JSON line isn't a clean run, unless resuming where nothing was added
+	if parsedLines == 0 && opts.StartOffset == 0 {
+		return "", report, ErrNoLogOutput
+	}
+
 	return buildErrorMessageFromLogs(errorsMap, fatalMap), report, nil
 }
 
-// unmarshal the JSON log line and extract important fields
-func parseLogLine(line string) (LogEntry, error) {
+// isJSONArray checks if the logs are a JSON array of entries rather than newline-delimited entries,
+// output starting with a bracket like "[1] Killed" isn't an array of objects
+func isJSONArray(reader *bufio.Reader) bool {
+	// Only the data of the first read is looked at, a tailed log may have nothing more yet
+	if _, err := reader.Peek(1); err != nil {
+		return false
+	}
+	data, _ := reader.Peek(reader.Buffered())
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) == 0 || data[0] != '[' {
+		return false
+	}
+	data = bytes.TrimLeft(data[1:], " \t\r\n")
+	return len(data) > 0 && (data[0] == '{' || data[0] == ']')
+}
+
+// tailReader reads past the end of the reader like tail -f, waiting for more data until the completion
+// message was processed, the deadline is reached or the context is cancelled
+type tailReader struct {
+	ctx       context.Context
+	reader    io.Reader
+	interval  time.Duration
+	deadline  time.Time // zero to wait without a deadline
+	completed func() bool
+}
+
+func (t *tailReader) Read(p []byte) (int, error) {
+	for {
+		n, err := t.reader.Read(p)
+		if !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+		if t.completed() || (!t.deadline.IsZero() && !time.Now().Before(t.deadline)) {
+			return 0, io.EOF
+		}
+
+		select {
+		case <-t.ctx.Done():
+			return 0, t.ctx.Err()
+		case <-time.After(t.interval):
+		}
+	}
+}
+
+// logFile is an opened log file, decompressing it when gzipped
+type logFile struct {
+	io.Reader
+	file    *os.File
+	gzip    *gzip.Reader
+	readErr error // the last read error other than io.EOF
+}
+
+func (f *logFile) Read(p []byte) (int, error) {
+	n, err := f.Reader.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		f.readErr = err
+	}
+	return n, err
+}
+
+func (f *logFile) Close() error {
+	if f.gzip != nil {
+		f.gzip.Close()
+	}
+	return f.file.Close()
+}
+
+// openLogFile opens the log file at the given offset, which counts decompressed bytes for gzip files.
+// The offset falls back to 0 when the file was truncated or replaced since
+func openLogFile(logFilePath string, offset int64) (*logFile, int64, error) {
+	file, err := os.Open(logFilePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	opened := &logFile{Reader: file, file: file}
+
+	if !isGzipFile(file, logFilePath) {
+		if offset > 0 {
+			if info, err := file.Stat(); err == nil && info.Size() < offset {
+				offset = 0
+			}
+			if _, err := file.Seek(offset, io.SeekStart); err != nil {
+				file.Close()
+				return nil, 0, fmt.Errorf("failed to seek log file to offset %d: %w", offset, err)
+			}
+		}
+		return opened, offset, nil
+	}
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to read gzip log file %s: %w", logFilePath, err)
+	}
+	opened.Reader, opened.gzip = gzipReader, gzipReader
+
+	if offset > 0 {
+		skipped, err := io.CopyN(io.Discard, gzipReader, offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			opened.Close()
+			return nil, 0, fmt.Errorf("corrupt gzip log file %s: %w", logFilePath, err)
+		}
+		if skipped < offset {
+			opened.Close()
+			return openLogFile(logFilePath, 0)
+		}
+	}
+	return opened, offset, nil
+}
+
+// isGzipFile checks the extension and the magic bytes of the file for gzip compression
+func isGzipFile(file *os.File, logFilePath string) bool {
+	if strings.HasSuffix(logFilePath, ".gz") {
+		return true
+	}
+	magic := make([]byte, 2)
+	if _, err := file.ReadAt(magic, 0); err != nil {
+		return false
+	}
+	return magic[0] == 0x1f && magic[1] == 0x8b
+}
+
+// waitForFile checks up to attempts times whether the file exists, waiting interval between the checks
+func waitForFile(ctx context.Context, filePath string, attempts int, interval time.Duration) bool {
+	for attempt := 1; ; attempt++ {
+		if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+			return true
+		}
+		if attempt >= attempts {
+			return false
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(interval):
+		}
+	}
+}
+
+// isNoisyDependency checks if the log line is about a dependency matching any of the glob patterns
+func isNoisyDependency(line *LogEntry, patterns []string) bool {
+	depName, ok := line.Extras["depName"].(string)
+	if !ok || depName == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, depName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isCrashLine checks if a raw, non-JSON line matches any crash pattern
+func isCrashLine(line string) bool {
+	for _, pattern := range crashPatterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// lineSnippet shortens the text of a log line to maxSnippetBytes, without splitting a character
+func lineSnippet(line string) string {
+	if len(line) <= maxSnippetBytes {
+		return line
+	}
+	cut := maxSnippetBytes
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "..."
+}
+
+// unmarshal the JSON log line and extract important fields,
+// optionally returning the required fields that are missing or invalid
+func parseLogLine(line string, validateSchema bool) (LogEntry, []string, error) {
 	var rawData map[string]any
 	if err := json.Unmarshal([]byte(line), &rawData); err != nil {
-		return LogEntry{}, fmt.Errorf("error unmarshalling JSON: %w", err)
+		return LogEntry{}, nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+
+	var violations []string
+	if validateSchema {
+		violations = validateLogSchema(rawData)
 	}
 
 	// assign known fields to the final structure
@@ -144,14 +654,61 @@ func parseLogLine(line string) (LogEntry, error) {
 			}
 
 			entry.Msg = msgStr
+		// keep the log time, preferring "time" over "timestamp"
+		case "time":
+			if logTime, ok := parseLogTime(v); ok {
+				entry.Time = logTime
+			}
+		case "timestamp":
+			if logTime, ok := parseLogTime(v); ok && entry.Time.IsZero() {
+				entry.Time = logTime
+			}
 		// keep only relevant extra fields
 		case "err", "errors", "errorMessage", "branch", "durationMs", "depName",
 			"branchesInformation", "context", "packageFile", "currentValue",
-			"previousNewValue", "thisNewValue", "oldConfig", "newConfig", "migratedConfig":
+			"previousNewValue", "thisNewValue", "oldConfig", "newConfig", "migratedConfig",
+			"enabledManagers", "warnings", "files", "fileList", "includePaths", "ignorePaths",
+			"packageRules", "repository", "sourceUrl",
+			"newValue", "manager", "validationSource", "validationMessage",
+			"registryUrl", "heapUsed", "heapTotal", "rss", "url", "retryAfter":
 			entry.Extras[k] = v
 		}
 	}
-	return entry, nil
+	return entry, violations, nil
+}
+
+// parseLogTime parses a log time logged as an RFC 3339 string or as epoch milliseconds
+func parseLogTime(value any) (time.Time, bool) {
+	switch value := value.(type) {
+	case string:
+		logTime, err := time.Parse(time.RFC3339Nano, value)
+		return logTime, err == nil
+	case float64:
+		return time.UnixMilli(int64(value)).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// validateLogSchema returns the required fields missing from the raw log line or having an unexpected type
+func validateLogSchema(rawData map[string]any) []string {
+	var violations []string
+	for _, field := range requiredLogFields {
+		value, found := rawData[field]
+		switch field {
+		case "level":
+			_, found = value.(float64)
+		case "msg":
+			_, found = value.(string)
+		case "time":
+			_, isString := value.(string)
+			_, isNumber := value.(float64)
+			found = isString || isNumber
+		}
+		if !found {
+			violations = append(violations, field)
+		}
+	}
+	return violations
 }
 
 // process structured logs to find errors/fatals and build a summary message
@@ -168,6 +725,45 @@ func buildErrorMessageFromLogs(errorsMap, fatalMap map[string]int) string {
 		fatalString)
 }
 
+// failureKeys returns the sorted ERROR and FATAL messages, prefixed with their level
+func failureKeys(errorsMap, fatalMap map[string]int) []string {
+	var keys []string
+	for formattedErr := range errorsMap {
+		keys = append(keys, "ERROR\x00"+formattedErr)
+	}
+	for formattedErr := range fatalMap {
+		keys = append(keys, "FATAL\x00"+formattedErr)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// countRepositoryMessage counts a formatted error or fatal message of the given repository
+func countRepositoryMessage(repoMap map[string]map[string]int, repository, formattedErr string) {
+	if repoMap[repository] == nil {
+		repoMap[repository] = make(map[string]int)
+	}
+	repoMap[repository][formattedErr]++
+}
+
+// build the failure message of each repository with errors or fatals
+func buildRepositoryFailures(repoErrorsMap, repoFatalMap map[string]map[string]int) map[string]string {
+	if len(repoErrorsMap) == 0 && len(repoFatalMap) == 0 {
+		return nil
+	}
+
+	failures := make(map[string]string)
+	for repository := range repoErrorsMap {
+		failures[repository] = buildErrorMessageFromLogs(repoErrorsMap[repository], repoFatalMap[repository])
+	}
+	for repository := range repoFatalMap {
+		if _, found := failures[repository]; !found {
+			failures[repository] = buildErrorMessageFromLogs(nil, repoFatalMap[repository])
+		}
+	}
+	return failures
+}
+
 // create summary with counts for duplicates
 func formatFailMsg(logs map[string]int, logLevel string) string {
 	if len(logs) == 0 {
@@ -190,8 +786,13 @@ func formatFailMsg(logs map[string]int, logLevel string) string {
 	return fmt.Sprintf("%d %s: %s", totalCount, logLevel, strings.Join(uniqueMessages, ""))
 }
 
-// build a single error message from a log entry, including nested error details if available
+// build a single error message from a log entry, including nested error details if available, with the secrets masked
 func buildErrorMessage(logEntry LogEntry) string {
+	return redactSecrets(formatErrorMessage(logEntry))
+}
+
+// formatErrorMessage formats the message of an error log entry with its error details
+func formatErrorMessage(logEntry LogEntry) string {
 	errMsg := logEntry.Msg
 
 	// Try to get additional error details
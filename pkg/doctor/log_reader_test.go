@@ -0,0 +1,270 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// paddedLine returns a log line of the PR limit warning padded to exactly size bytes
+func paddedLine(t *testing.T, size int) string {
+	t.Helper()
+	line := `{"level":40,"msg":"Reached PR limit - skipping PR creation","pad":""}`
+	if len(line) > size {
+		t.Fatalf("line size %d is below the %d bytes of the unpadded line", size, len(line))
+	}
+	return strings.Replace(line, `"pad":""`, `"pad":"`+strings.Repeat("x", size-len(line))+`"`, 1)
+}
+
+func TestMaxLineBytes(t *testing.T) {
+	const maxLineBytes = 256
+	tests := []struct {
+		name        string
+		size        int
+		wantSkipped bool
+	}{
+		{name: "below the limit", size: maxLineBytes - 1},
+		{name: "at the limit", size: maxLineBytes},
+		{name: "past the limit", size: maxLineBytes + 1, wantSkipped: true},
+		{name: "far past the limit", size: 4 * maxLineBytes, wantSkipped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := paddedLine(t, tt.size)
+			var logs bytes.Buffer
+			opts := Options{MaxLineBytes: maxLineBytes, Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+			// A line after the one of the boundary size is still processed
+			report := processLines(t, opts, line, `{"level":30,"msg":"not within schedule"}`)
+
+			processed := len(reportEntries(report, "PR limit reached")) == 1
+			if processed == tt.wantSkipped {
+				t.Errorf("line of %d bytes processed = %v, want %v", tt.size, processed, !tt.wantSkipped)
+			}
+			// The skipped line is logged, the report only has the findings of the analyzed run
+			wantLog := `msg="Skipped a log line exceeding the maximum line size" line=1 limit=256`
+			if strings.Contains(logs.String(), wantLog) != tt.wantSkipped {
+				t.Errorf("logs = %q, want the skipped line warning %v", logs.String(), tt.wantSkipped)
+			}
+			wantStat := 0
+			if tt.wantSkipped {
+				wantStat = 1
+			}
+			if report.Stats.SkippedLines != wantStat {
+				t.Errorf("skipped lines = %d, want %d", report.Stats.SkippedLines, wantStat)
+			}
+			if len(report.Errors)+len(report.Warnings) != 1-wantStat {
+				t.Errorf("report errors %q and warnings %q, want only the findings of the processed lines", report.Errors, report.Warnings)
+			}
+			if len(reportEntries(report, "Renovate skipped work outside of the configured schedule")) != 1 {
+				t.Error("the line after the long line wasn't processed")
+			}
+		})
+	}
+}
+
+func TestNoLogOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "zero-byte file", content: "", wantErr: true},
+		{name: "blank lines", content: "\n\n  \n", wantErr: true},
+		{name: "junk only", content: "not json\n{broken\n}\n", wantErr: true},
+		{name: "junk and a log line", content: "not json\n{\"level\":30,\"msg\":\"Repository finished\"}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "renovate-logs.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, report, err := ProcessLogFile(context.Background(), path, Options{})
+			if errors.Is(err, ErrNoLogOutput) != tt.wantErr {
+				t.Fatalf("ProcessLogFile() error = %v, want ErrNoLogOutput %v", err, tt.wantErr)
+			}
+			if report == nil {
+				t.Fatal("ProcessLogFile() returned no report")
+			}
+		})
+	}
+}
+
+func TestJSONArrayLog(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantNoOutput bool
+		wantErr      bool
+		wantWarnings int
+	}{
+		{name: "empty array", content: "[]", wantNoOutput: true},
+		{name: "empty array with whitespace", content: " [ \n ]\n", wantNoOutput: true},
+		{
+			name:         "trailing whitespace",
+			content:      "[\n" + `{"level":40,"msg":"Reached PR limit - skipping PR creation"}` + "\n]\n\n  \t\n",
+			wantWarnings: 1,
+		},
+		{name: "malformed first element", content: `[{"level":40 "msg":"Reached PR limit - skipping PR creation"}]`, wantErr: true},
+		{name: "truncated first element", content: `[{"level":40,"msg":"Reached PR li`, wantErr: true},
+		// The elements decoded before the malformed one are still reported
+		{
+			name:         "truncated array",
+			content:      `[{"level":40,"msg":"Reached PR limit - skipping PR creation"},` + "\n" + `{"level":30,"msg":"Repos`,
+			wantWarnings: 1,
+		},
+		{
+			name:         "missing comma",
+			content:      `[{"level":40,"msg":"Reached PR limit - skipping PR creation"} {"level":30,"msg":"Repository finished"}]`,
+			wantWarnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, report, err := ProcessLogReader(context.Background(), strings.NewReader(tt.content), Options{})
+			switch {
+			case tt.wantErr:
+				if err == nil || !strings.Contains(err.Error(), "invalid JSON array log") {
+					t.Fatalf("ProcessLogReader() error = %v, want an invalid JSON array error", err)
+				}
+			case tt.wantNoOutput:
+				if !errors.Is(err, ErrNoLogOutput) {
+					t.Fatalf("ProcessLogReader() error = %v, want ErrNoLogOutput", err)
+				}
+			case err != nil:
+				t.Fatalf("ProcessLogReader() error = %v", err)
+			}
+			if !tt.wantErr && len(report.Warnings) != tt.wantWarnings {
+				t.Errorf("report warnings = %q, want %d", report.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestProcessLogFilesCancel(t *testing.T) {
+	const cancelMsg = "Cancel the processing"
+	prLimit := `{"level":40,"msg":"Reached PR limit - skipping PR creation"}`
+	schedule := `{"level":30,"msg":"not within schedule"}`
+	tests := []struct {
+		name      string
+		files     []string
+		wantErr   bool
+		wantLines int
+	}{
+		// The second file isn't started once the first one cancelled the processing
+		{
+			name:      "between files with findings",
+			files:     []string{prLimit + "\n" + `{"level":30,"msg":"` + cancelMsg + `"}`, schedule},
+			wantLines: 2,
+		},
+		{
+			name:      "between files without findings",
+			files:     []string{`{"level":30,"msg":"` + cancelMsg + `"}`, prLimit},
+			wantErr:   true,
+			wantLines: 1,
+		},
+		// The lines are processed up to the next periodic check
+		{
+			name:      "mid-file",
+			files:     []string{prLimit + "\n" + `{"level":30,"msg":"` + cancelMsg + `"}` + strings.Repeat("\n"+`{"level":30,"msg":"Repository finished"}`, 150) + "\n" + schedule},
+			wantLines: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var paths []string
+			for i, content := range tt.files {
+				path := filepath.Join(dir, fmt.Sprintf("renovate-logs-%d.json", i))
+				if err := os.WriteFile(path, []byte(content+"\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, path)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			selectors := RegisteredSelectors()
+			selectors[cancelMsg] = func(line *LogEntry, report *SimpleReport) { cancel() }
+
+			_, report, _, err := ProcessLogFiles(ctx, paths, Options{Selectors: selectors})
+			if tt.wantErr {
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("ProcessLogFiles() error = %v, want context.Canceled", err)
+				}
+			} else if err != nil {
+				t.Fatalf("ProcessLogFiles() error = %v, want the partial report", err)
+			}
+			if report.Stats.LinesProcessed != tt.wantLines {
+				t.Errorf("lines processed = %d, want %d", report.Stats.LinesProcessed, tt.wantLines)
+			}
+			if !tt.wantErr && len(reportEntries(report, "PR limit reached")) != 1 {
+				t.Errorf("report warnings = %q, want the findings before the cancellation", report.Warnings)
+			}
+			if len(reportEntries(report, "Renovate skipped work outside of the configured schedule")) != 0 {
+				t.Error("a line after the cancellation was processed")
+			}
+		})
+	}
+}
+
+func TestTailPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "renovate-logs.json")
+	first := `{"level":30,"msg":"Repository started"}` + "\n"
+	if err := os.WriteFile(path, []byte(first), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The last line is written in two flushes and never completed by a line break before the cancellation
+	line := `{"level":40,"msg":"Reached PR limit - skipping PR creation"}`
+	go func() {
+		for _, part := range []string{line[:20], line[20:]} {
+			time.Sleep(30 * time.Millisecond)
+			if _, err := file.WriteString(part); err != nil {
+				t.Error(err)
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		cancel()
+	}()
+
+	_, report, err := ProcessLogFile(ctx, path, Options{Tail: true, TailInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("ProcessLogFile() error = %v, want the partial report", err)
+	}
+	if len(reportEntries(report, "PR limit reached")) != 1 {
+		t.Errorf("report warnings = %q, want the partial line checked", report.Warnings)
+	}
+	// The partial line is read again when resuming
+	if report.Stats.EndOffset != int64(len(first)) {
+		t.Errorf("end offset = %d, want %d", report.Stats.EndOffset, len(first))
+	}
+}
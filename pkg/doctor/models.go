@@ -14,16 +14,73 @@
 
 package doctor
 
+import (
+	"log/slog"
+	"regexp"
+	"time"
+)
+
 // Structured format for each log
 type LogEntry struct {
 	Level  string
 	Msg    string
+	Time   time.Time      // When the entry was logged, zero if the time is missing or invalid
 	Extras map[string]any // Additional structured data
 }
 
+// Options configures how the logs are processed
+type Options struct {
+	ValidateSchema   bool          // Validate each parsed line against the expected Renovate log schema
+	FileWaitAttempts int           // How many times to check for the log file before giving up, at least once
+	FileWaitInterval time.Duration // How long to wait between the checks for the log file
+	OptionalChecks   []string      // Names of the opt-in checks to run, e.g. "pr-summary"
+	StartOffset      int64         // Byte offset to resume reading the log file from, e.g. a previous EndOffset
+	NoisyDeps        []string      // Glob patterns of dependency names whose errors and warnings are reported as infos
+	LineNumbers      bool          // Add the number of the log line that triggered each report message
+	WarningPatterns  []string      // Regexes of volatile substrings, e.g. durations, ignored when de-duplicating warnings
+	Branch           string        // Only process the entries of this branch and the entries without a branch
+	MaxLineBytes     int           // Longest log line processed, longer lines are skipped, defaults to 1 MB
+	MaxErrorLines    int           // Lines kept of long error messages in the report, defaults to 8
+	Tail             bool          // Keep reading the log files past their end, like tail -f, while they are written
+	TailUntil        string        // Message of the log entry that completes a tailed log, e.g. "Renovate exiting"
+	TailInterval     time.Duration // How long to wait for more logs at the end of a tailed log, defaults to 1s
+	TailTimeout      time.Duration // Longest time to tail the logs for, zero to tail until completed or cancelled
+	Logger           *slog.Logger  // Logs the skipped long lines and, at debug level, the lines failing to parse when set
+
+	// Selectors checked instead of the registered ones when set, e.g. RegisteredSelectors with custom checks
+	Selectors map[string]CheckFunc
+	// Match modes of the selectors over the registered ones, the selectors without one are matched as substrings
+	SelectorModes map[string]MatchMode
+}
+
+// LogStats holds statistics about the processed log lines
+type LogStats struct {
+	SchemaViolations int   // Parsed lines missing a required field, counted only with schema validation
+	EndOffset        int64 // Byte offset after the last complete line read, to resume processing from
+	Downgraded       int   // Error log lines and report messages of noisy dependencies downgraded to infos
+	LinesProcessed   int   // Log lines read, from the start offset on
+	ParseErrors      int   // Non-empty log lines that aren't valid JSON
+	OutsideSchedule  int   // Log entries of work skipped because of the configured schedule
+	SkippedLines     int   // Log lines exceeding the maximum line size, skipped without being checked
+}
+
 // SimpleReport holds categorized log messages
 type SimpleReport struct {
 	Errors   []string
 	Warnings []string
 	Infos    []string
+	Stats    LogStats
+
+	// Failure reason of each repository with ERROR or FATAL entries, by the "repository" field of the
+	// entries, entries logged outside of any repository are under an empty key
+	RepositoryFailures map[string]string
+
+	selector      string            // selector of the check currently adding messages
+	downgrade     bool              // report the errors and warnings of the current log line as infos
+	lineNumber    int               // number of the log line currently checked, only when line numbers are enabled
+	normalizers   []*regexp.Regexp  // volatile substrings ignored when comparing warnings
+	maxErrorLines int               // lines kept of long error messages, zero for the default
+	selectors     map[string]string // selector that produced each message
+	counts        map[string]int    // counters of the aggregating checks
+	failures      []string          // ERROR and FATAL log messages failing the run, matched by a check or not
 }
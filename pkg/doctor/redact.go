@@ -0,0 +1,105 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// redactedText replaces the secrets found in report messages and fail reasons
+const redactedText = "[REDACTED]"
+
+// minSecretBlobLength is the shortest base64 blob taken for a secret, shorter ones are usually identifiers
+const minSecretBlobLength = 40
+
+// redactionRule replaces the matches of the pattern, the replacement can reference the pattern groups
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// redactionRules match common secret shapes besides the URL credentials
+var redactionRules = []redactionRule{
+	{regexp.MustCompile(`(?i)(\bbearer\s+|authorization:\s*(?:basic|bearer|token)\s+)[A-Za-z0-9\-._~+/]{8,}=*`), "${1}" + redactedText},
+	{regexp.MustCompile(`\b(ghp|gho|ghu|ghs|ghr|github_pat)_[A-Za-z0-9_]{20,}`), redactedText},
+	{regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}`), redactedText},
+}
+
+// secretBlobRe matches encoded secret candidates, which are only redacted if they look random;
+// "/" and "+" are left out so that long paths and URLs are not taken for secrets
+var secretBlobRe = regexp.MustCompile(`[A-Za-z0-9]{` + fmt.Sprint(minSecretBlobLength) + `,}={0,2}`)
+
+// urlCredentialsRe matches the user info of URLs, e.g. "https://x-access-token:secret@"
+var urlCredentialsRe = regexp.MustCompile(`(https?://)[^/\s'"@]+@`)
+
+// redactURLCredentials removes the user info of the URLs in a message, keeping the scheme and the host
+func redactURLCredentials(message string) string {
+	return urlCredentialsRe.ReplaceAllString(message, "${1}")
+}
+
+// AddRedactionPatterns compiles the regexes and adds them to the built-in secret patterns, their matches are
+// replaced as a whole. Nothing is added if any of them is invalid or empty, an empty regex matches everywhere
+func AddRedactionPatterns(patterns []string) error {
+	rules := make([]redactionRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("empty redaction pattern")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		rules = append(rules, redactionRule{pattern: re, replacement: redactedText})
+	}
+	redactionRules = append(redactionRules, rules...)
+	return nil
+}
+
+// redactSecrets masks the secrets in a message before it is reported or sent
+func redactSecrets(message string) string {
+	message = redactURLCredentials(message)
+	for _, rule := range redactionRules {
+		message = rule.pattern.ReplaceAllString(message, rule.replacement)
+	}
+	var redacted strings.Builder
+	last := 0
+	for _, match := range secretBlobRe.FindAllStringIndex(message, -1) {
+		start, end := match[0], match[1]
+		if isPathSegment(message, start, end) || !isRandomBlob(message[start:end]) {
+			continue
+		}
+		redacted.WriteString(message[last:start])
+		redacted.WriteString(redactedText)
+		last = end
+	}
+	redacted.WriteString(message[last:])
+	return redacted.String()
+}
+
+// isPathSegment checks if the text between start and end is delimited by a "/" like a segment of a path or URL
+func isPathSegment(message string, start, end int) bool {
+	return (start > 0 && message[start-1] == '/') || (end < len(message) && message[end] == '/')
+}
+
+// isRandomBlob checks if the blob mixes upper case letters, lower case letters and digits like encoded
+// secrets do, unlike hex digests, commit hashes or paths
+func isRandomBlob(blob string) bool {
+	return strings.IndexFunc(blob, unicode.IsUpper) >= 0 &&
+		strings.IndexFunc(blob, unicode.IsLower) >= 0 &&
+		strings.IndexFunc(blob, unicode.IsDigit) >= 0
+}
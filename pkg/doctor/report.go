@@ -15,32 +15,336 @@
 package doctor
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"regexp"
 	"slices"
 	"strings"
 )
 
+// rootCausePatterns match error classes that commonly cascade into many per-dependency errors
+var rootCausePatterns = []struct {
+	class   string
+	pattern *regexp.Regexp
+}{
+	{"DNS lookup failure", regexp.MustCompile(`\b(ENOTFOUND|EAI_AGAIN)\b`)},
+	{"connection refused", regexp.MustCompile(`\bECONNREFUSED\b`)},
+	{"connection reset", regexp.MustCompile(`\bECONNRESET\b`)},
+	{"connection timeout", regexp.MustCompile(`\bETIMEDOUT\b`)},
+	{"TLS certificate verification failure", regexp.MustCompile(`(?i)certificate verify failed`)},
+	{"server error", regexp.MustCompile(`\b50[0234] (Internal Server Error|Bad Gateway|Service Unavailable|Gateway Timeout)\b`)},
+}
+
+// rootCauseHostRe matches the host of the first URL in a message
+var rootCauseHostRe = regexp.MustCompile(`https?://([^/\s'"]+)`)
+
+// volatileFieldRe matches report fields whose values change between runs with the same findings
+var volatileFieldRe = regexp.MustCompile(` \| (Duration|Line): [^|\n]*`)
+
+// lineFieldRe matches the line number field of report messages
+var lineFieldRe = regexp.MustCompile(` \| Line: \d+`)
+
 func (r *SimpleReport) Error(msg string, fields ...interface{}) {
-	formatted := formatSimpleMessage(msg, fields)
+	if r.downgrade {
+		r.Stats.Downgraded++
+		r.Info(msg, fields...)
+		return
+	}
+	// The same error on another line is still a duplicate
+	if hasMessage(r.Errors, formatSimpleMessage(msg, fields), nil) {
+		return
+	}
+	formatted := formatSimpleMessage(msg, r.withLineNumber(fields))
+	r.trackSelector(formatted)
 	r.Errors = append(r.Errors, formatted)
 }
 
 func (r *SimpleReport) Warning(msg string, fields ...interface{}) {
-	formatted := formatSimpleMessage(msg, fields)
-	if slices.Contains(r.Warnings, formatted) {
+	if r.downgrade {
+		r.Stats.Downgraded++
+		r.Info(msg, fields...)
 		return
 	}
+	// The same warning on another line or with other volatile values is still a duplicate
+	if hasMessage(r.Warnings, formatSimpleMessage(msg, fields), r.normalizeWarning) {
+		return
+	}
+	formatted := formatSimpleMessage(msg, r.withLineNumber(fields))
+	r.trackSelector(formatted)
 	r.Warnings = append(r.Warnings, formatted)
 }
 
 func (r *SimpleReport) Info(msg string, fields ...interface{}) {
-	formatted := formatSimpleMessage(msg, fields)
+	if hasMessage(r.Infos, formatSimpleMessage(msg, fields), nil) {
+		return
+	}
+	formatted := formatSimpleMessage(msg, r.withLineNumber(fields))
+	r.trackSelector(formatted)
 	r.Infos = append(r.Infos, formatted)
 }
 
+// hasMessage checks if the logs already contain the message, ignoring their line numbers,
+// after applying the optional normalization to both
+func hasMessage(logs []string, message string, normalize func(string) string) bool {
+	if normalize == nil {
+		normalize = func(formatted string) string { return formatted }
+	}
+	message = normalize(message)
+	return slices.ContainsFunc(logs, func(log string) bool {
+		return normalize(lineFieldRe.ReplaceAllString(log, "")) == message
+	})
+}
+
+// Merge adds the messages of the other report in their order, skipping the ones already in the report
+// the same way as when they are reported, and adds up the stats. A nil report is ignored
+func (r *SimpleReport) Merge(other *SimpleReport) {
+	if other == nil {
+		return
+	}
+
+	merge := func(logs, otherLogs []string, normalize func(string) string) []string {
+		for _, formatted := range otherLogs {
+			if hasMessage(logs, lineFieldRe.ReplaceAllString(formatted, ""), normalize) {
+				continue
+			}
+			logs = append(logs, formatted)
+			if selector := other.Selector(formatted); selector != "" {
+				if r.selectors == nil {
+					r.selectors = make(map[string]string)
+				}
+				r.selectors[formatted] = selector
+			}
+		}
+		return logs
+	}
+	r.Errors = merge(r.Errors, other.Errors, nil)
+	r.Warnings = merge(r.Warnings, other.Warnings, r.normalizeWarning)
+	r.Infos = merge(r.Infos, other.Infos, nil)
+
+	// The offset reached is the one of the last merged report
+	r.Stats.SchemaViolations += other.Stats.SchemaViolations
+	r.Stats.EndOffset = other.Stats.EndOffset
+	r.Stats.Downgraded += other.Stats.Downgraded
+	r.Stats.LinesProcessed += other.Stats.LinesProcessed
+	r.Stats.ParseErrors += other.Stats.ParseErrors
+	r.Stats.SkippedLines += other.Stats.SkippedLines
+
+	for key, count := range other.counts {
+		if r.counts == nil {
+			r.counts = make(map[string]int)
+		}
+		r.counts[key] += count
+	}
+	r.Stats.OutsideSchedule = r.counts["outsideSchedule"]
+	r.failures = append(r.failures, other.failures...)
+	// The failure of a repository already in the report is kept
+	for repository, failure := range other.RepositoryFailures {
+		if r.RepositoryFailures == nil {
+			r.RepositoryFailures = make(map[string]string)
+		}
+		if _, found := r.RepositoryFailures[repository]; !found {
+			r.RepositoryFailures[repository] = failure
+		}
+	}
+}
+
+// Selector returns the selector of the check that produced the given message,
+// or an empty string if it wasn't produced by a registered check
+func (r *SimpleReport) Selector(formatted string) string {
+	return r.selectors[formatted]
+}
+
+// Fingerprint returns a stable hash of the errors and warnings in the report and of the ERROR and FATAL
+// log messages failing the run, ignoring their order and volatile field values like durations, or an empty
+// string if there are none. Reports with the same findings have the same fingerprint across runs.
+func (r *SimpleReport) Fingerprint() string {
+	var keys []string
+	// Runs failing on log messages no check matched differ by these only
+	for _, failure := range r.failures {
+		keys = append(keys, "failure\x00"+failure)
+	}
+	for _, formatted := range r.Errors {
+		keys = append(keys, r.fingerprintKey("error", formatted))
+	}
+	for _, formatted := range r.Warnings {
+		keys = append(keys, r.fingerprintKey("warning", formatted))
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	sum := sha256.Sum256([]byte(strings.Join(keys, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprintKey builds the key identifying a report message for the fingerprint
+func (r *SimpleReport) fingerprintKey(severity, formatted string) string {
+	return strings.Join([]string{severity, r.Selector(formatted), volatileFieldRe.ReplaceAllString(formatted, "")}, "\x00")
+}
+
+// withLineNumber prepends the number of the log line currently checked to the fields, if it is tracked
+func (r *SimpleReport) withLineNumber(fields []interface{}) []interface{} {
+	if r.lineNumber == 0 {
+		return fields
+	}
+	return append([]interface{}{"Line", r.lineNumber}, fields...)
+}
+
+// normalizeWarning replaces the volatile substrings of a warning to compare it with the other warnings
+func (r *SimpleReport) normalizeWarning(formatted string) string {
+	for _, normalizer := range r.normalizers {
+		formatted = normalizer.ReplaceAllString(formatted, "#")
+	}
+	return formatted
+}
+
+// count increments the counter with the given key, used by checks aggregating multiple log lines
+func (r *SimpleReport) count(key string) {
+	if r.counts == nil {
+		r.counts = make(map[string]int)
+	}
+	r.counts[key]++
+}
+
+// trackSelector remembers the selector of the currently running check for a message
+func (r *SimpleReport) trackSelector(formatted string) {
+	if r.selector == "" {
+		return
+	}
+	if r.selectors == nil {
+		r.selectors = make(map[string]string)
+	}
+	if _, found := r.selectors[formatted]; !found {
+		r.selectors[formatted] = r.selector
+	}
+}
+
+// CorrelateErrors groups errors sharing the same root cause (the same error class on the same host)
+// into a single entry listing the affected errors. Errors without both a known error class and a host
+// are kept as they are, so that genuinely different failures are never merged.
+func (r *SimpleReport) CorrelateErrors() {
+	groups := make(map[string][]string)
+	for _, formatted := range r.Errors {
+		if signature := rootCauseSignature(formatted); signature != "" {
+			groups[signature] = append(groups[signature], formatted)
+		}
+	}
+
+	reported := make(map[string]bool)
+	correlated := make([]string, 0, len(r.Errors))
+	for _, formatted := range r.Errors {
+		signature := rootCauseSignature(formatted)
+		group := groups[signature]
+		if signature == "" || len(group) < 2 {
+			correlated = append(correlated, formatted)
+			continue
+		}
+		// the group is reported at the position of its first error
+		if reported[signature] {
+			continue
+		}
+		reported[signature] = true
+
+		var affected []string
+		for _, member := range group {
+			summary, _, _ := strings.Cut(member, "\n")
+			affected = append(affected, fmt.Sprintf("\n- %s", summary))
+		}
+		correlated = append(correlated, fmt.Sprintf("%d errors caused by %s%s", len(group), signature, strings.Join(affected, "")))
+	}
+	r.Errors = correlated
+}
+
+// branchFieldKeys are the report fields that differ between the branches hitting the same error
+var branchFieldKeys = []string{"Branch", "Duration", "Line"}
+
+// GroupBranchErrors reports the errors that differ only by their branch, duration or line once,
+// with the list of the affected branches. The errors are grouped by their message and other fields,
+// so that genuinely different errors are never merged.
+func (r *SimpleReport) GroupBranchErrors() {
+	groups := make(map[string][]string)
+	for _, formatted := range r.Errors {
+		if key, branch := splitBranchError(formatted); branch != "" && !slices.Contains(groups[key], branch) {
+			groups[key] = append(groups[key], branch)
+		}
+	}
+
+	reported := make(map[string]bool)
+	grouped := make([]string, 0, len(r.Errors))
+	for _, formatted := range r.Errors {
+		key, branch := splitBranchError(formatted)
+		branches := groups[key]
+		if branch == "" || len(branches) < 2 {
+			grouped = append(grouped, formatted)
+			continue
+		}
+		// the group is reported at the position of its first error
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+
+		header, message, found := strings.Cut(key, "\nMessage: ")
+		groupedError := fmt.Sprintf("%s | Branches: %s", header, strings.Join(branches, ", "))
+		if found {
+			groupedError += "\nMessage: " + message
+		}
+		if selector := r.Selector(formatted); selector != "" {
+			r.selectors[groupedError] = selector
+		}
+		grouped = append(grouped, groupedError)
+	}
+	r.Errors = grouped
+}
+
+// splitBranchError returns the error without its branch fields, which is its grouping key,
+// and its branch, empty if the error has none
+func splitBranchError(formatted string) (string, string) {
+	header, message, found := strings.Cut(formatted, "\nMessage: ")
+	parts := strings.Split(header, " | ")
+
+	branch := ""
+	kept := parts[:1]
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, ": ")
+		if key == "Branch" && value != "" && value != "<nil>" {
+			branch = value
+		}
+		if !slices.Contains(branchFieldKeys, key) {
+			kept = append(kept, part)
+		}
+	}
+
+	withoutBranch := strings.Join(kept, " | ")
+	if found {
+		withoutBranch += "\nMessage: " + message
+	}
+	return withoutBranch, branch
+}
+
+// rootCauseSignature returns the error class and host of a message, or an empty string if either is unknown
+func rootCauseSignature(formatted string) string {
+	hostMatches := rootCauseHostRe.FindStringSubmatch(formatted)
+	if hostMatches == nil {
+		return ""
+	}
+
+	for _, rootCause := range rootCausePatterns {
+		if rootCause.pattern.MatchString(formatted) {
+			return fmt.Sprintf("%s on %s", rootCause.class, hostMatches[1])
+		}
+	}
+	return ""
+}
+
+// formatSimpleMessage formats the message with its fields, masking the secrets they may contain
 func formatSimpleMessage(msg string, fields []interface{}) string {
 	if len(fields) == 0 {
-		return msg
+		return redactSecrets(msg)
 	}
 
 	var result strings.Builder
@@ -59,5 +363,5 @@ func formatSimpleMessage(msg string, fields []interface{}) string {
 		}
 	}
 
-	return result.String()
+	return redactSecrets(result.String())
 }
@@ -0,0 +1,135 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name         string
+		report       *SimpleReport
+		other        *SimpleReport
+		wantErrors   []string
+		wantWarnings []string
+		wantStats    LogStats
+		wantFailures map[string]string
+	}{
+		{
+			name:   "into an empty report",
+			report: &SimpleReport{},
+			other: &SimpleReport{
+				Errors:             []string{"Error executing command | Branch: main"},
+				Warnings:           []string{"PR limit reached - skipping PR creation"},
+				Stats:              LogStats{LinesProcessed: 3, ParseErrors: 1, EndOffset: 120},
+				RepositoryFailures: map[string]string{"org/repo": "Mintmaker finished with 1 ERROR"},
+			},
+			wantErrors:   []string{"Error executing command | Branch: main"},
+			wantWarnings: []string{"PR limit reached - skipping PR creation"},
+			wantStats:    LogStats{LinesProcessed: 3, ParseErrors: 1, EndOffset: 120},
+			wantFailures: map[string]string{"org/repo": "Mintmaker finished with 1 ERROR"},
+		},
+		{
+			name: "with duplicates",
+			report: &SimpleReport{
+				Errors:             []string{"Error executing command | Branch: main | Line: 4"},
+				Stats:              LogStats{LinesProcessed: 10, EndOffset: 500},
+				RepositoryFailures: map[string]string{"org/repo": "first failure"},
+			},
+			other: &SimpleReport{
+				// The same error on another line is a duplicate, a new one is added after the existing ones
+				Errors:             []string{"Error executing command | Branch: main | Line: 12", "No go.mod found"},
+				Stats:              LogStats{LinesProcessed: 5, EndOffset: 200},
+				RepositoryFailures: map[string]string{"org/repo": "second failure", "org/other": "other failure"},
+			},
+			wantErrors:   []string{"Error executing command | Branch: main | Line: 4", "No go.mod found"},
+			wantStats:    LogStats{LinesProcessed: 15, EndOffset: 200},
+			wantFailures: map[string]string{"org/repo": "first failure", "org/other": "other failure"},
+		},
+		{
+			name: "nil report",
+			report: &SimpleReport{
+				Errors: []string{"No go.mod found"},
+				Stats:  LogStats{LinesProcessed: 2, EndOffset: 80},
+			},
+			wantErrors: []string{"No go.mod found"},
+			wantStats:  LogStats{LinesProcessed: 2, EndOffset: 80},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.report.Merge(tt.other)
+			if !slices.Equal(tt.report.Errors, tt.wantErrors) {
+				t.Errorf("errors = %q, want %q", tt.report.Errors, tt.wantErrors)
+			}
+			if !slices.Equal(tt.report.Warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", tt.report.Warnings, tt.wantWarnings)
+			}
+			if tt.report.Stats != tt.wantStats {
+				t.Errorf("stats = %+v, want %+v", tt.report.Stats, tt.wantStats)
+			}
+			if len(tt.report.RepositoryFailures) != len(tt.wantFailures) {
+				t.Errorf("repository failures = %v, want %v", tt.report.RepositoryFailures, tt.wantFailures)
+			}
+			for repository, failure := range tt.wantFailures {
+				if tt.report.RepositoryFailures[repository] != failure {
+					t.Errorf("failure of %s = %q, want %q", repository, tt.report.RepositoryFailures[repository], failure)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeCounts(t *testing.T) {
+	report := &SimpleReport{}
+	report.count("outsideSchedule")
+	other := &SimpleReport{}
+	other.count("outsideSchedule")
+	other.count("prsCreated")
+
+	report.Merge(other)
+	if report.counts["outsideSchedule"] != 2 || report.counts["prsCreated"] != 1 {
+		t.Errorf("counts = %v, want outsideSchedule 2 and prsCreated 1", report.counts)
+	}
+	if report.Stats.OutsideSchedule != 2 {
+		t.Errorf("Stats.OutsideSchedule = %d, want 2", report.Stats.OutsideSchedule)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	unmatched := `{"level":50,"msg":"Repository has an unknown failure"}`
+	otherUnmatched := `{"level":50,"msg":"Repository has another unknown failure"}`
+	clean := processLines(t, Options{}, `{"level":30,"msg":"Repository finished"}`)
+	failed := processLines(t, Options{}, unmatched)
+	if clean.Fingerprint() != "" {
+		t.Errorf("clean run fingerprint = %q, want none", clean.Fingerprint())
+	}
+	// Runs failing only on log messages no check matched are told apart
+	if failed.Fingerprint() == "" {
+		t.Error("failed run without report entries has no fingerprint")
+	}
+	if failed.Fingerprint() == processLines(t, Options{}, otherUnmatched).Fingerprint() {
+		t.Error("different failures have the same fingerprint")
+	}
+	if failed.Fingerprint() != processLines(t, Options{}, unmatched, unmatched).Fingerprint() {
+		t.Error("the same failure has another fingerprint")
+	}
+	both := processLines(t, Options{}, otherUnmatched, unmatched)
+	if both.Fingerprint() != processLines(t, Options{}, unmatched, otherUnmatched).Fingerprint() {
+		t.Error("the fingerprint depends on the order of the failures")
+	}
+}
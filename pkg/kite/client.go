@@ -17,71 +17,241 @@ package kite
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/tracing"
 )
 
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL        string
+	httpClient     *http.Client
+	deadLetterFile string
+	token          string        // bearer token sent with every request, never logged
+	dryRunLogger   *slog.Logger  // logs the webhooks instead of sending them when set
+	requestTimeout time.Duration // deadline of each request, on top of the parent context
+	retryAttempts  int           // attempts to send a webhook, including the first one
+	retryDelay     time.Duration // delay before the first retry, doubled for every further one
 }
 
+// ErrUnauthorized is wrapped by the errors of requests Kite rejected with 401 Unauthorized
+var ErrUnauthorized = errors.New("Kite API rejected the authentication")
+
+const (
+	defaultRequestTimeout = 10 * time.Second
+	defaultRetryAttempts  = 3
+	defaultRetryDelay     = 1 * time.Second
+)
+
 type HealthResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 }
 
+// downStatuses are the health statuses of a Kite API that can't take webhooks
+var downStatuses = []string{"down", "unhealthy", "unavailable", "error"}
+
+// IsDown checks if the status reports the Kite API as unable to serve requests, a degraded API still serves them
+func (h *HealthResponse) IsDown() bool {
+	return slices.ContainsFunc(downStatuses, func(status string) bool {
+		return strings.EqualFold(strings.TrimSpace(h.Status), status)
+	})
+}
+
+// String formats the health as "status: message", with placeholders for missing values
+func (h *HealthResponse) String() string {
+	statusStr := h.Status
+	if statusStr == "" {
+		statusStr = "unknown status"
+	}
+
+	messageStr := h.Message
+	if messageStr == "" {
+		messageStr = "unknown status detail"
+	}
+
+	return fmt.Sprintf("%s: %s", statusStr, messageStr)
+}
+
 type PipelineFailurePayload struct {
-	PipelineName  string `json:"pipelineName"`
-	Namespace     string `json:"namespace"`
-	FailureReason string `json:"failureReason"`
-	RunID         string `json:"runId,omitempty"`
-	LogsURL       string `json:"logsUrl,omitempty"`
+	PipelineName  string            `json:"pipelineName"`
+	Namespace     string            `json:"namespace"`
+	FailureReason string            `json:"failureReason"`
+	RunID         string            `json:"runId,omitempty"`
+	LogsURL       string            `json:"logsUrl,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Fingerprint   string            `json:"fingerprint,omitempty"`
 }
 
 type PipelineSuccessPayload struct {
-	PipelineName string `json:"pipelineName"`
-	Namespace    string `json:"namespace"`
+	PipelineName    string            `json:"pipelineName"`
+	Namespace       string            `json:"namespace"`
+	Warnings        int               `json:"warnings"`
+	Infos           int               `json:"infos"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	OutsideSchedule bool              `json:"outsideSchedule,omitempty"`
+	Fingerprint     string            `json:"fingerprint,omitempty"`
 }
 
+// CustomPayload is the mintmaker-custom webhook payload, the repository, branch and pipeline run
+// are always sent, empty if unknown, so consumers don't have to parse them out of PipelineId
 type CustomPayload struct {
-	PipelineId string   `json:"pipelineId"`
-	Namespace  string   `json:"namespace"`
-	Type       string   `json:"type"`
-	Logs       []string `json:"logs"`
+	PipelineId  string            `json:"pipelineId"`
+	Namespace   string            `json:"namespace"`
+	Repository  string            `json:"repository"`
+	Branch      string            `json:"branch"`
+	PipelineRun string            `json:"pipelineRun"`
+	Type        string            `json:"type"`
+	Logs        []string          `json:"logs"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Stats       map[string]int    `json:"stats,omitempty"`
 }
 
-// NewClient creates a new Kite API client
+// NewClient creates a new Kite API client, a "unix:///path/to/socket"
+// base URL sends the requests over the given Unix domain socket
 func NewClient(baseURL string) (*Client, error) {
 	if baseURL == "" {
 		return nil, fmt.Errorf("Kite API base URL cannot be empty")
 	}
 
-	_, err := url.Parse(baseURL)
+	u, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid base URL: %w", err)
 	}
 
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid base URL %s: missing host", baseURL)
+		}
+	case "unix":
+		socketPath := u.Path
+		if socketPath == "" {
+			return nil, fmt.Errorf("invalid base URL %s: missing Unix socket path", baseURL)
+		}
+
+		httpClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		// the host is not used for the connection, the request paths are built as usual
+		baseURL = "http://kite"
+	case "":
+		return nil, fmt.Errorf("invalid base URL %s: must be an absolute http, https or unix URL", baseURL)
+	default:
+		return nil, fmt.Errorf("invalid base URL %s: unsupported scheme %q, must be http, https or unix", baseURL, u.Scheme)
+	}
+
 	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:        baseURL,
+		httpClient:     httpClient,
+		requestTimeout: defaultRequestTimeout,
+		retryAttempts:  defaultRetryAttempts,
+		retryDelay:     defaultRetryDelay,
 	}, nil
 }
 
+// SetRequestTimeout sets the deadline of each request, so a hung request can't use up the whole run,
+// zero only keeps the 30-second HTTP client timeout
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	c.requestTimeout = timeout
+}
+
+// withRequestTimeout derives the context of a single request from the parent context
+func (c *Client) withRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
+// requestTimedOut checks if the request failed because its own deadline fired rather than the parent context
+func requestTimedOut(ctx, reqCtx context.Context, err error) bool {
+	return err != nil && ctx.Err() == nil && errors.Is(reqCtx.Err(), context.DeadlineExceeded)
+}
+
+// SetHTTPClient replaces the HTTP client sending the requests, e.g. to share a transport with other clients
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetCAFile makes the client trust the PEM certificates of the given file on top of the system ones,
+// for a Kite API behind an internal CA; the proxy settings of HTTPS_PROXY and NO_PROXY still apply
+func (c *Client) SetCAFile(caFile string) error {
+	pemData, err := os.ReadFile(caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(pemData) {
+		return fmt.Errorf("no PEM certificates found in CA file %s", caFile)
+	}
+
+	// the default transport is cloned to keep its connection reuse and proxy handling
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	transport.TLSClientConfig.RootCAs = rootCAs
+	c.httpClient.Transport = transport
+	return nil
+}
+
+// SetToken makes the client authenticate every request with the given bearer token
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// SetDryRun makes the client log the webhooks with the given logger instead of sending them
+func (c *Client) SetDryRun(logger *slog.Logger) {
+	c.dryRunLogger = logger
+}
+
+// SetRetry configures how many times a webhook is sent on network errors and 5xx responses,
+// waiting delay before the first retry and doubling it for every further one
+func (c *Client) SetRetry(attempts int, delay time.Duration) {
+	c.retryAttempts = max(attempts, 1)
+	c.retryDelay = delay
+}
+
 // sendRequest sends the given request to Kite API and stores
-// the decoded response body in the value pointed to by out
-func (c *Client) sendRequest(req *http.Request, out any) error {
+// the decoded response body in the value pointed to by out,
+// the response status code is returned if a response was received
+func (c *Client) sendRequest(req *http.Request, out any) (int, error) {
 	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return 0, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -92,50 +262,85 @@ func (c *Client) sendRequest(req *http.Request, out any) error {
 		if readErr == nil {
 			responseBody = string(bodyBytes)
 		}
-		return fmt.Errorf("Kite API returned status code %d: %s", resp.StatusCode, responseBody)
+		if resp.StatusCode == http.StatusUnauthorized {
+			return resp.StatusCode, fmt.Errorf("%w (status code %d): %s", ErrUnauthorized, resp.StatusCode, responseBody)
+		}
+		return resp.StatusCode, fmt.Errorf("Kite API returned status code %d: %s", resp.StatusCode, responseBody)
 	}
 
 	if out != nil {
 		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+			return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
 		}
 	}
 
-	return nil
+	return resp.StatusCode, nil
 }
 
-// GetVersion returns the Kite API version
-func (c *Client) GetKiteStatus(ctx context.Context) (string, error) {
+// GetKiteHealth returns the health reported by the Kite API
+func (c *Client) GetKiteHealth(ctx context.Context) (*HealthResponse, error) {
 	// baseURL is already validated in NewClient, so this should never fail
 	u, _ := url.Parse(c.baseURL)
 	u.Path = path.Join(u.Path, "api/v1/health")
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	reqCtx, cancel := c.withRequestTimeout(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var respBody HealthResponse
-	if err := c.sendRequest(req, &respBody); err != nil {
-		return "", err
+	if _, err := c.sendRequest(req, &respBody); err != nil {
+		if requestTimedOut(ctx, reqCtx, err) {
+			return nil, fmt.Errorf("Kite API status request timed out after %s: %w", c.requestTimeout, err)
+		}
+		return nil, err
 	}
+	return &respBody, nil
+}
 
-	statusStr := respBody.Status
-	if statusStr == "" {
-		statusStr = "unknown status"
+// GetKiteStatus returns the Kite API health as a "status: message" string for logging
+func (c *Client) GetKiteStatus(ctx context.Context) (string, error) {
+	health, err := c.GetKiteHealth(ctx)
+	if err != nil {
+		return "", err
 	}
+	return health.String(), nil
+}
 
-	messageStr := respBody.Message
-	if messageStr == "" {
-		messageStr = "unknown status detail"
+// WebhookExists probes whether Kite has a webhook with the given name, any response
+// other than 404 Not Found means the webhook route is registered
+func (c *Client) WebhookExists(ctx context.Context, name string) (bool, error) {
+	// baseURL is already validated in NewClient, so this should never fail
+	u, _ := url.Parse(c.baseURL)
+	u.Path = path.Join(u.Path, "api/v1/webhooks", name)
+
+	reqCtx, cancel := c.withRequestTimeout(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return fmt.Sprintf("%s: %s", statusStr, messageStr), nil
+	statusCode, err := c.sendRequest(req, nil)
+	switch {
+	case statusCode == http.StatusNotFound:
+		return false, nil
+	case statusCode != 0:
+		return true, nil
+	default:
+		return false, err
+	}
 }
 
 // SendWebhookRequest creates the URL, adds the namespace to the query parameters,
 // creates a request, and sends it to Kite API
-func (c *Client) SendWebhookRequest(ctx context.Context, namespace string, webhookName string, payload []byte) error {
+func (c *Client) SendWebhookRequest(ctx context.Context, namespace string, webhookName string, payload []byte) (err error) {
+	ctx, span := tracing.Start(ctx, "kite.webhook")
+	span.SetAttribute("kite.webhook", webhookName)
+	defer func() { span.End(err) }()
+
 	// baseURL is already validated in NewClient, so this should never fail
 	u, _ := url.Parse(c.baseURL)
 	u.Path = path.Join(u.Path, "api/v1/webhooks", webhookName)
@@ -144,10 +349,56 @@ func (c *Client) SendWebhookRequest(ctx context.Context, namespace string, webho
 	q.Set("namespace", namespace)
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(payload))
+	if c.dryRunLogger != nil {
+		c.dryRunLogger.Info("Dry run, webhook not sent", "url", u.String(), "payload", string(payload))
+		return nil
+	}
+
+	statusCode, attempts, err := c.postWithRetry(ctx, u.String(), payload)
+	span.SetAttribute("kite.webhook.attempts", attempts)
+	span.SetAttribute("http.response.status_code", statusCode)
+	if err != nil && c.deadLetterFile != "" {
+		// Keep the payload to replay it later
+		if deadLetterErr := c.writeDeadLetter(namespace, webhookName, payload, err); deadLetterErr != nil {
+			return fmt.Errorf("%w, %w", err, deadLetterErr)
+		}
+		return fmt.Errorf("%w: %w", ErrDeadLettered, err)
+	}
+	return err
+}
+
+// post sends a single webhook request with its own deadline
+func (c *Client) post(ctx context.Context, webhookURL string, payload []byte) (int, error) {
+	reqCtx, cancel := c.withRequestTimeout(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, webhookURL, bytes.NewReader(payload))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	statusCode, err := c.sendRequest(req, nil)
+	if requestTimedOut(ctx, reqCtx, err) {
+		return statusCode, fmt.Errorf("webhook request timed out after %s: %w", c.requestTimeout, err)
 	}
+	return statusCode, err
+}
+
+// postWithRetry posts the payload, retrying with exponential backoff on network errors and 5xx responses,
+// it returns the last response status code and the number of attempts made
+func (c *Client) postWithRetry(ctx context.Context, webhookURL string, payload []byte) (int, int, error) {
+	delay := c.retryDelay
+	for attempt := 1; ; attempt++ {
+		statusCode, err := c.post(ctx, webhookURL, payload)
+		retryable := statusCode == 0 || statusCode >= 500
+		if err == nil || !retryable || attempt >= c.retryAttempts {
+			return statusCode, attempt, err
+		}
 
-	return c.sendRequest(req, nil)
+		select {
+		case <-ctx.Done():
+			return statusCode, attempt, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
 }
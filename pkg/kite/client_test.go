@@ -0,0 +1,70 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kite
+
+import "testing"
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		wantErr bool
+	}{
+		{name: "empty URL", baseURL: "", wantErr: true},
+		{name: "scheme-less URL", baseURL: "kite.example.com", wantErr: true},
+		{name: "scheme-less URL with port", baseURL: "kite.example.com:8080/api", wantErr: true},
+		{name: "missing host", baseURL: "https://", wantErr: true},
+		{name: "unsupported scheme", baseURL: "ftp://kite.example.com", wantErr: true},
+		{name: "missing socket path", baseURL: "unix://", wantErr: true},
+		{name: "https URL", baseURL: "https://kite.example.com"},
+		{name: "http URL with port and path", baseURL: "http://localhost:8080/kite"},
+		{name: "unix socket", baseURL: "unix:///var/run/kite.sock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(tt.baseURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClient(%q) error = %v, want error %v", tt.baseURL, err, tt.wantErr)
+			}
+			if !tt.wantErr && client == nil {
+				t.Fatalf("NewClient(%q) returned no client", tt.baseURL)
+			}
+		})
+	}
+}
+
+func TestHealthResponseIsDown(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{status: "healthy"},
+		{status: "ok"},
+		{status: "degraded"},
+		{status: ""},
+		{status: "down", want: true},
+		{status: "Unhealthy", want: true},
+		{status: " unavailable ", want: true},
+		{status: "error", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			health := &HealthResponse{Status: tt.status}
+			if got := health.IsDown(); got != tt.want {
+				t.Errorf("IsDown() of status %q = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
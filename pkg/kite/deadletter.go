@@ -0,0 +1,64 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrDeadLettered is wrapped by the errors of webhooks that couldn't be sent but were written to the dead-letter file
+var ErrDeadLettered = errors.New("webhook written to the dead-letter file")
+
+// DeadLetter is a webhook that couldn't be sent, stored as a JSON line in the dead-letter file to be replayed later
+type DeadLetter struct {
+	Time      time.Time       `json:"time"`
+	Namespace string          `json:"namespace"`
+	Webhook   string          `json:"webhook"`
+	Payload   json.RawMessage `json:"payload"`
+	Error     string          `json:"error"`
+}
+
+// SetDeadLetterFile makes the client append webhooks that fail to be sent to the given file
+func (c *Client) SetDeadLetterFile(filePath string) {
+	c.deadLetterFile = filePath
+}
+
+// writeDeadLetter appends the failed webhook to the dead-letter file
+func (c *Client) writeDeadLetter(namespace, webhookName string, payload []byte, sendErr error) error {
+	line, err := json.Marshal(DeadLetter{
+		Time:      time.Now().UTC(),
+		Namespace: namespace,
+		Webhook:   webhookName,
+		Payload:   payload,
+		Error:     sendErr.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+
+	file, err := os.OpenFile(c.deadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics records the metrics of an analysis run, e.g. as Prometheus metrics
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// metricPrefix is prepended to the names of the Prometheus metrics
+const metricPrefix = "renovate_log_analyzer_"
+
+// Run holds the metrics of an analysis run
+type Run struct {
+	Failed         bool // The run sent a failure webhook
+	Errors         int
+	Warnings       int
+	Infos          int
+	LinesProcessed int
+	ParseErrors    int
+	WebhooksSent   int
+	WebhooksFailed int
+	Duration       time.Duration
+	Time           time.Time // When the run finished
+}
+
+// Recorder records the metrics of a run once it is over
+type Recorder interface {
+	Record(ctx context.Context, run Run) error
+}
+
+// writeText writes the run as Prometheus text exposition format gauges with the given labels
+func writeText(w io.Writer, run Run, labels map[string]string) error {
+	var labelList []string
+	for _, key := range sortedKeys(labels) {
+		labelList = append(labelList, fmt.Sprintf("%s=%q", key, labels[key]))
+	}
+	labelSuffix := ""
+	if len(labelList) > 0 {
+		labelSuffix = "{" + strings.Join(labelList, ",") + "}"
+	}
+
+	failed := 0
+	if run.Failed {
+		failed = 1
+	}
+
+	gauges := []struct {
+		name  string
+		help  string
+		value float64
+	}{
+		{"failed", "Whether the run failed", float64(failed)},
+		{"errors", "Errors in the report", float64(run.Errors)},
+		{"warnings", "Warnings in the report", float64(run.Warnings)},
+		{"infos", "Infos in the report", float64(run.Infos)},
+		{"lines_processed", "Log lines read", float64(run.LinesProcessed)},
+		{"parse_errors", "Log lines that are not valid JSON", float64(run.ParseErrors)},
+		{"webhooks_sent", "Webhooks sent to Kite", float64(run.WebhooksSent)},
+		{"webhooks_failed", "Webhooks that failed to be sent to Kite", float64(run.WebhooksFailed)},
+		{"duration_seconds", "Duration of the run", run.Duration.Seconds()},
+		{"last_run_timestamp_seconds", "When the run finished", float64(run.Time.Unix())},
+	}
+	for _, gauge := range gauges {
+		name := metricPrefix + gauge.name
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s%s %g\n", name, gauge.help, name, name, labelSuffix, gauge.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the labels in order, for a stable output
+func sortedKeys(labels map[string]string) []string {
+	return slices.Sorted(maps.Keys(labels))
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TextFileRecorder writes the metrics in the Prometheus text format to a file, e.g. for the node exporter textfile collector
+type TextFileRecorder struct {
+	Path   string
+	Labels map[string]string
+}
+
+func (r TextFileRecorder) Record(_ context.Context, run Run) error {
+	var buf bytes.Buffer
+	if err := writeText(&buf, run, r.Labels); err != nil {
+		return fmt.Errorf("failed to encode metrics: %w", err)
+	}
+
+	// Replace the file at once so the collector never reads a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(r.Path), filepath.Base(r.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write metrics file %s: %w", r.Path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics file %s: %w", r.Path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics file %s: %w", r.Path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write metrics file %s: %w", r.Path, err)
+	}
+	if err := os.Rename(tmp.Name(), r.Path); err != nil {
+		return fmt.Errorf("failed to write metrics file %s: %w", r.Path, err)
+	}
+	return nil
+}
+
+// PushgatewayRecorder pushes the metrics to a Prometheus Pushgateway, grouped by the job and the labels
+type PushgatewayRecorder struct {
+	URL    string
+	Job    string
+	Labels map[string]string
+}
+
+func (r PushgatewayRecorder) Record(ctx context.Context, run Run) error {
+	pushURL, err := url.Parse(r.URL)
+	if err != nil {
+		return fmt.Errorf("invalid Pushgateway URL: %w", err)
+	}
+	pushURL = pushURL.JoinPath("metrics", groupingPath("job", r.Job))
+	for _, key := range sortedKeys(r.Labels) {
+		pushURL = pushURL.JoinPath(groupingPath(key, r.Labels[key]))
+	}
+
+	// The grouping labels are part of the URL, the metrics themselves don't repeat them
+	var buf bytes.Buffer
+	if err := writeText(&buf, run, nil); err != nil {
+		return fmt.Errorf("failed to encode metrics: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, pushURL.String(), &buf)
+	if err != nil {
+		return fmt.Errorf("failed to create Pushgateway request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Pushgateway returned status code %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// groupingPath builds the URL path segment of a grouping label, empty values and values with a slash are base64 encoded
+func groupingPath(key, value string) string {
+	if value == "" {
+		return fmt.Sprintf("%s@base64/=", key)
+	}
+	if strings.Contains(value, "/") {
+		return fmt.Sprintf("%s@base64/%s", key, base64.RawURLEncoding.EncodeToString([]byte(value)))
+	}
+	return fmt.Sprintf("%s/%s", key, value)
+}
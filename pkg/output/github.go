@@ -0,0 +1,67 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/doctor"
+)
+
+// annotationEscaper escapes the characters GitHub Actions treats specially in workflow command messages
+var annotationEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// WriteGitHubAnnotations writes the fail reason and the report entries as
+// GitHub Actions workflow commands, so they show up as annotations in the checks UI
+func WriteGitHubAnnotations(w io.Writer, failReason string, report *doctor.SimpleReport) error {
+	if failReason != "" {
+		if err := writeAnnotation(w, "error", "Renovate failed", failReason); err != nil {
+			return err
+		}
+	}
+
+	annotations := []struct {
+		level string
+		logs  []string
+	}{
+		{"error", report.Errors},
+		{"warning", report.Warnings},
+		{"notice", report.Infos},
+	}
+	for _, annotation := range annotations {
+		for _, log := range annotation.logs {
+			if err := writeAnnotation(w, annotation.level, "", log); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeAnnotation writes a single workflow command with an optional title
+func writeAnnotation(w io.Writer, level, title, message string) error {
+	params := ""
+	if title != "" {
+		params = " title=" + title
+	}
+
+	if _, err := fmt.Fprintf(w, "::%s%s::%s\n", level, params, annotationEscaper.Replace(message)); err != nil {
+		return fmt.Errorf("failed to write %s annotation: %w", level, err)
+	}
+	return nil
+}
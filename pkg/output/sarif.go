@@ -0,0 +1,115 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/doctor"
+)
+
+const (
+	sarifVersion  = "2.1.0"
+	sarifSchema   = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName = "renovate-log-analyzer"
+
+	// failureRuleID is the rule of the fail reason built from ERROR and FATAL log entries
+	failureRuleID = "renovate-failure"
+	// reportRuleID is the rule of report entries not produced by a registered check, e.g. summaries
+	reportRuleID = "report"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID  string       `json:"ruleId"`
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// WriteSARIF writes the fail reason and the report entries as a SARIF document,
+// using the selectors of the checks that produced the entries as rule IDs
+func WriteSARIF(w io.Writer, failReason string, report *doctor.SimpleReport) error {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: sarifToolName, Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+
+	knownRules := make(map[string]bool)
+	addResult := func(ruleID, level, message string) {
+		if !knownRules[ruleID] {
+			knownRules[ruleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: ruleID})
+		}
+		run.Results = append(run.Results, sarifResult{RuleID: ruleID, Level: level, Message: sarifMessage{Text: message}})
+	}
+
+	if failReason != "" {
+		addResult(failureRuleID, "error", failReason)
+	}
+
+	results := []struct {
+		level string
+		logs  []string
+	}{
+		{"error", report.Errors},
+		{"warning", report.Warnings},
+		{"note", report.Infos},
+	}
+	for _, result := range results {
+		for _, log := range result.logs {
+			ruleID := report.Selector(log)
+			if ruleID == "" {
+				ruleID = reportRuleID
+			}
+			addResult(ruleID, result.level, log)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}}); err != nil {
+		return fmt.Errorf("failed to write SARIF document: %w", err)
+	}
+	return nil
+}
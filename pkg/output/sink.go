@@ -0,0 +1,109 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/konflux-ci/renovate-log-analyzer/pkg/doctor"
+)
+
+// Verdict is the outcome of the analysis the report was produced by
+type Verdict struct {
+	FailReason string // Why the run failed, empty for a successful run
+}
+
+// ReportSink emits the report and the verdict of an analysis to a consumer
+type ReportSink interface {
+	Emit(ctx context.Context, report *doctor.SimpleReport, verdict Verdict) error
+}
+
+// GitHubAnnotationsSink prints the report as GitHub Actions annotations
+type GitHubAnnotationsSink struct {
+	Writer io.Writer
+}
+
+func (s GitHubAnnotationsSink) Emit(_ context.Context, report *doctor.SimpleReport, verdict Verdict) error {
+	if err := WriteGitHubAnnotations(s.Writer, verdict.FailReason, report); err != nil {
+		return fmt.Errorf("failed to print GitHub annotations: %w", err)
+	}
+	return nil
+}
+
+// SARIFFileSink writes the report as a SARIF document to a file
+type SARIFFileSink struct {
+	Path string
+}
+
+func (s SARIFFileSink) Emit(_ context.Context, report *doctor.SimpleReport, verdict Verdict) error {
+	file, err := os.Create(s.Path)
+	if err != nil {
+		return fmt.Errorf("failed to write SARIF report to %s: %w", s.Path, err)
+	}
+	if err := WriteSARIF(file, verdict.FailReason, report); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write SARIF report to %s: %w", s.Path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write SARIF report to %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// JSONReport is the JSON document written by JSONFileSink
+type JSONReport struct {
+	PipelineIdentifier string               `json:"pipelineIdentifier"`
+	Namespace          string               `json:"namespace"`
+	FailReason         string               `json:"failReason"`
+	Report             *doctor.SimpleReport `json:"report"`
+	// Baseline keeps the selectors of the report entries for the BASELINE_REPORT of the next run
+	Baseline *doctor.BaselineReport `json:"baseline"`
+}
+
+// JSONFileSink writes the report and the fail reason as JSON to a file, or to stdout for "-",
+// e.g. for dashboards or to be used as the baseline of the next run
+type JSONFileSink struct {
+	Path               string
+	PipelineIdentifier string
+	Namespace          string
+}
+
+func (s JSONFileSink) Emit(_ context.Context, report *doctor.SimpleReport, verdict Verdict) error {
+	data, err := json.MarshalIndent(JSONReport{
+		PipelineIdentifier: s.PipelineIdentifier,
+		Namespace:          s.Namespace,
+		FailReason:         verdict.FailReason,
+		Report:             report,
+		Baseline:           doctor.NewBaselineReport(report),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+
+	if s.Path == "-" {
+		if _, err := os.Stdout.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write JSON report to stdout: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(s.Path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON report to %s: %w", s.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statsd sends metrics to a StatsD endpoint over UDP, with DogStatsD style tags
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	conn   net.Conn
+	prefix string
+	tags   string
+}
+
+// NewClient creates a StatsD client sending the metrics to addr, with the prefix prepended
+// to the metric names and the tags added to every metric
+func NewClient(addr, prefix string, tags map[string]string) (*Client, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD: %w", err)
+	}
+
+	var tagList []string
+	for key, value := range tags {
+		tagList = append(tagList, fmt.Sprintf("%s:%s", key, value))
+	}
+	slices.Sort(tagList)
+
+	tagSuffix := ""
+	if len(tagList) > 0 {
+		tagSuffix = "|#" + strings.Join(tagList, ",")
+	}
+
+	return &Client{conn: conn, prefix: prefix, tags: tagSuffix}, nil
+}
+
+// Gauge sends the current value of a metric
+func (c *Client) Gauge(name string, value int) error {
+	return c.send(name, fmt.Sprintf("%d|g", value))
+}
+
+// Count sends an increment of a counter
+func (c *Client) Count(name string, value int) error {
+	return c.send(name, fmt.Sprintf("%d|c", value))
+}
+
+// Timing sends a duration in milliseconds
+func (c *Client) Timing(name string, duration time.Duration) error {
+	return c.send(name, fmt.Sprintf("%d|ms", duration.Milliseconds()))
+}
+
+// Close closes the connection to the StatsD endpoint
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// send writes a single metric as its own packet
+func (c *Client) send(name, value string) error {
+	if _, err := fmt.Fprintf(c.conn, "%s%s:%s%s", c.prefix, name, value, c.tags); err != nil {
+		return fmt.Errorf("failed to send metric %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+// Copyright 2025 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing provides optional tracing of the analyzer phases.
+// The default build uses a no-op implementation, building with the "otel"
+// tag exports the spans with OpenTelemetry, configured via the standard OTEL_* env vars.
+package tracing
+
+// Span is a single traced operation
+type Span interface {
+	// SetAttribute adds an attribute describing the operation to the span
+	SetAttribute(key string, value any)
+	// End finishes the span, marking it as failed when err is not nil
+	End(err error)
+}